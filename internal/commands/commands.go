@@ -2,10 +2,17 @@ package commands
 
 import (
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/carlisia/markin/internal/config"
+	"github.com/carlisia/markin/internal/dateparse"
+	"github.com/carlisia/markin/internal/entry"
 	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
@@ -16,50 +23,182 @@ const (
 	reset  = "\033[0m"
 )
 
-// NewFlCmd creates a command for adding a fleeting note
-func NewFlCmd(cfg *config.Config, debug bool) *cobra.Command {
+// newEntryCmd creates a command for adding an entry of the given type. The entry
+// type is looked up again in the configuration loaded when the command runs.
+func newEntryCmd(a *app, et config.EntryType) *cobra.Command {
+	var tags []string
+	var fields map[string]string
+	var period string
+	var to string
+	var date, at string
+
 	cmd := &cobra.Command{
-		Use:   "fl [note]",
-		Short: "Add a fleeting note to your daily note",
-		Long: `Add a fleeting note to your daily note file.
-The note will be added under the configured section.`,
-		Args: cobra.ExactArgs(1),
+		Use:   et.Name + " [note...]",
+		Short: et.Description,
+		Long: fmt.Sprintf(`%s.
+The entry will be added under the %q section.
+
+The note is the arguments joined by spaces. Without arguments it is read from
+stdin when piped, or written in $EDITOR. Lines after the first are nested under
+the entry.`, et.Description, et.Section),
+		Args:        cobra.ArbitraryArgs,
+		Annotations: map[string]string{annotationConfig: "required"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			note := args[0]
-			timestamp := time.Now().Format("03:04:05 pm")
-			formattedNote := fmt.Sprintf("- ⚡ *%s:* **Fleeting**:: %s", timestamp, note)
-			if err := markdown.AddLine(
-				cfg.ProjectDir,
-				cfg.DailyNotePath,
-				cfg.DailyNoteName,
-				cfg.Section,
-				formattedNote,
-				cfg.Position,
-				cfg.CreateSectionIfMissing,
-				debug,
-			); err != nil {
+			cfg, debug := a.cfg, a.debug
+			et, err := entryType(cfg, et.Name)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			now, err := entryTime(time.Now(), date, at)
+			if err != nil {
+				return err
+			}
+			note, err := readNote(args, os.Stdin, isPiped(os.Stdin), func() (string, error) {
+				return editNote(runEditor)
+			})
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			formattedNote, err := formatEntry(cfg, et, now, markdown.Sanitize(note, cfg.Sanitize), tags, fields)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			target, fullPath, err := resolveTarget(a.fsys, cfg, et, period, to, now, debug)
+			if err != nil {
 				cmd.SilenceUsage = true
-				return fmt.Errorf("failed to add fleeting note: %w", err)
+				return fmt.Errorf("failed to add %s entry: %w", et.Name, err)
+			}
+			inserter := markdown.NewInserter(markdown.WithOptions(markdown.Options{
+				Section:                target.Section,
+				Match:                  cfg.SectionMatch,
+				SectionAliases:         cfg.SectionAliases,
+				Position:               markdown.Position(et.Position),
+				CreateSectionIfMissing: cfg.CreateSectionIfMissing,
+				Subheading:             et.Subheading,
+				Marker:                 et.Marker,
+				SectionOrder:           target.SectionOrder,
+				ScaffoldSections:       target.ScaffoldSections,
+				Template:               target.Template,
+				Time:                   now,
+				TimeZone:               cfg.Location(),
+				Debug:                  debug,
+			}), markdown.WithStore(newStore(a.fsys, cfg, debug)))
+			if err := inserter.Insert(cmd.Context(), fullPath, formattedNote); err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("failed to add %s entry: %w", et.Name, err)
 			}
 			return nil
 		},
 	}
+
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Tag to attach to the entry (repeatable)")
+	cmd.Flags().StringToStringVarP(&fields, "field", "f", nil, "Custom field available to the entry format as .Fields.<key> (key=value, repeatable)")
+	cmd.Flags().StringVarP(&period, "period", "p", "", "Periodic note to add the entry to: "+strings.Join(config.Periods, ", ")+" (default: the entry type's period, or daily)")
+	cmd.Flags().StringVar(&to, "to", "", "Note to add the entry to instead of the daily note, by path relative to project_dir or by title or alias")
+	cmd.Flags().StringVar(&date, "date", "", `Day of the entry, e.g. yesterday, 2026-10-01 or "last friday" (default: today)`)
+	cmd.Flags().StringVar(&at, "at", "", "Time of the entry, e.g. 14:30 or 2:30pm (default: now)")
+	cmd.MarkFlagsMutuallyExclusive("period", "to")
 	return cmd
 }
 
-// NewInitCmd creates a command for initializing the configuration
-func NewInitCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "init",
-		Short: "Initialize the configuration file",
-		Long: `Initialize the configuration file with default settings.
-This will create a sample configuration file in your home directory.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := config.GenerateSampleConfig(""); err != nil {
-				return fmt.Errorf("failed to generate sample configuration: %w", err)
-			}
-			fmt.Println("Configuration file created successfully!")
-			return nil
-		},
+// entryType returns the entry type of cfg with the given name
+func entryType(cfg *config.Config, name string) (config.EntryType, error) {
+	for _, et := range cfg.Types() {
+		if et.Name == name {
+			return et, nil
+		}
+	}
+	return config.EntryType{}, fmt.Errorf("entry type %q is not configured", name)
+}
+
+// entryTime returns the time an entry is stamped with and filed under: now, moved
+// to the day given by --date and the time of day given by --at. Dates and times are
+// read in now's zone, the one a plain capture picks today's note in; timezone only
+// changes how the timestamp is rendered.
+func entryTime(now time.Time, date, at string) (time.Time, error) {
+	t := now
+	var err error
+	if date != "" {
+		if t, err = dateparse.Date(date, t); err != nil {
+			return time.Time{}, fmt.Errorf("invalid --date: %w", err)
+		}
+	}
+	if at != "" {
+		if t, err = dateparse.At(at, t); err != nil {
+			return time.Time{}, fmt.Errorf("invalid --at: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// resolveTarget returns the note an entry goes to and its full path: the note
+// given by --to, or the periodic note of the entry type
+func resolveTarget(fsys afero.Fs, cfg *config.Config, et config.EntryType, period, to string, t time.Time, debug bool) (config.Target, string, error) {
+	if to != "" {
+		// Titles are looked up on the local disk, which doesn't hold a WebDAV vault
+		if cfg.Storage.IsWebDAV() && !markdown.IsNotePath(to) {
+			return config.Target{}, "", fmt.Errorf("note %q is given by title, notes on WebDAV can only be given by path, such as Folder/Note.md", to)
+		}
+		fullPath, err := markdown.ResolveNote(fsys, cfg.ProjectDir, to, debug)
+		if err != nil {
+			return config.Target{}, "", err
+		}
+		return config.Target{Section: et.Section}, fullPath, nil
+	}
+
+	target, err := cfg.Target(et, period)
+	if err != nil {
+		return config.Target{}, "", err
+	}
+	fullPath, err := markdown.ResolvePath(cfg.ProjectDir, target.Path, target.Name, t, debug)
+	if err != nil {
+		return config.Target{}, "", err
+	}
+	return target, fullPath, nil
+}
+
+// newStore returns the Store notes are read from and written to: fsys, or the
+// WebDAV share of the configuration
+func newStore(fsys afero.Fs, cfg *config.Config, debug bool) markdown.Store {
+	if !cfg.Storage.IsWebDAV() {
+		return markdown.FSStore{Fs: fsys, Debug: debug}
+	}
+	username, password := cfg.Storage.Credentials()
+	return markdown.WebDAVStore{
+		URL:      cfg.Storage.URL,
+		Username: username,
+		Password: password,
+		Debug:    debug,
 	}
 }
+
+// formatEntry renders a note with the entry type's format, merging the type's
+// default tags and fields with the ones given on the command line
+func formatEntry(cfg *config.Config, et config.EntryType, t time.Time, note string, tags []string, fields map[string]string) (string, error) {
+	formatter, err := entry.NewFormatter(et.EntryFormat, et.TimeFormat, cfg.Location())
+	if err != nil {
+		return "", err
+	}
+
+	allFields := make(map[string]string, len(et.Fields)+len(fields))
+	maps.Copy(allFields, et.Fields)
+	maps.Copy(allFields, fields)
+
+	dir, _ := os.Getwd()
+	host, _ := os.Hostname()
+
+	return formatter.Format(entry.Data{
+		Text:   note,
+		Time:   t,
+		Type:   et.Name,
+		Label:  et.Label,
+		Emoji:  et.Emoji,
+		Tags:   append(slices.Clone(et.Tags), tags...),
+		Dir:    dir,
+		Host:   host,
+		Fields: allFields,
+	})
+}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/spf13/afero"
+)
+
+func TestEntryTimeSameNote(t *testing.T) {
+	cfg := &config.Config{
+		ProjectDir:    "/vault",
+		DailyNotePath: "daily",
+		DailyNoteName: "{{.Date}}.md",
+		Timezone:      "UTC",
+	}
+	et := cfg.Types()[0]
+	// 8pm in Los Angeles is already the next day in UTC
+	now := time.Date(2026, time.October, 17, 20, 0, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+	_, plain, err := resolveTarget(afero.NewMemMapFs(), cfg, et, "", "", now, false)
+	if err != nil {
+		t.Fatalf("Failed to resolve target: %v", err)
+	}
+	if plain != "/vault/daily/2026-10-17.md" {
+		t.Fatalf("Expected today's local note, got %s", plain)
+	}
+
+	tests := []struct {
+		date string
+		at   string
+	}{
+		{"today", ""},
+		{"", "20:00"},
+		{"", "23:59"},
+		{"today", "9am"},
+	}
+
+	for _, tt := range tests {
+		entryAt, err := entryTime(now, tt.date, tt.at)
+		if err != nil {
+			t.Fatalf("Failed to get entry time for --date %q --at %q: %v", tt.date, tt.at, err)
+		}
+		_, path, err := resolveTarget(afero.NewMemMapFs(), cfg, et, "", "", entryAt, false)
+		if err != nil {
+			t.Fatalf("Failed to resolve target: %v", err)
+		}
+		if path != plain {
+			t.Errorf("With --date %q --at %q expected %s like a plain capture, got %s", tt.date, tt.at, plain, path)
+		}
+	}
+}
+
+func TestResolveTargetWebDAVTitle(t *testing.T) {
+	cfg := &config.Config{
+		ProjectDir: "Vault",
+		Storage:    config.Storage{Backend: config.StorageWebDAV, URL: "https://cloud.example.com/dav"},
+	}
+	et := cfg.Types()[0]
+	now := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		to       string
+		expected string
+		wantErr  bool
+	}{
+		{"Projects/Markin", "Vault/Projects/Markin.md", false},
+		{"[[Markin.md]]", "Vault/Markin.md", false},
+		{"Markin", "", true},
+		{"[[Markin|the project]]", "", true},
+	}
+
+	for _, tt := range tests {
+		_, path, err := resolveTarget(afero.NewMemMapFs(), cfg, et, "", tt.to, now, false)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "only be given by path") {
+				t.Errorf("Expected --to %q to be rejected as a title, got %v", tt.to, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Failed to resolve --to %q: %v", tt.to, err)
+		}
+		if path != tt.expected {
+			t.Errorf("Expected --to %q to resolve to %s, got %s", tt.to, tt.expected, path)
+		}
+	}
+}
+
+func TestReadNote(t *testing.T) {
+	errEditor := errors.New("editor crashed")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		piped    bool
+		edited   string
+		editErr  error
+		expected string
+		wantErr  error
+	}{
+		{name: "single argument", args: []string{"hello"}, expected: "hello"},
+		{name: "arguments joined", args: []string{"ship", "it", "today"}, expected: "ship it today"},
+		{name: "arguments win over stdin", args: []string{"from", "args"}, stdin: "from stdin", piped: true, expected: "from args"},
+		{name: "piped stdin", stdin: "from stdin\n", piped: true, expected: "from stdin"},
+		{name: "piped stdin trimmed", stdin: "\n\n  first\n  second  \n\n", piped: true, expected: "first\n  second"},
+		{name: "piped CRLF", stdin: "first\r\nsecond\r\n", piped: true, expected: "first\nsecond"},
+		{name: "empty stdin", stdin: " \r\n\n", piped: true, wantErr: errEmptyNote},
+		{name: "empty arguments", args: []string{"", " "}, wantErr: errEmptyNote},
+		{name: "editor", edited: "written\nin the editor\n", expected: "written\nin the editor"},
+		{name: "editor left empty", edited: "\n", wantErr: errEmptyNote},
+		{name: "editor failed", editErr: errEditor, wantErr: errEditor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := false
+			edit := func() (string, error) {
+				edited = true
+				return tt.edited, tt.editErr
+			}
+
+			got, err := readNote(tt.args, strings.NewReader(tt.stdin), tt.piped, edit)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to read note: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, got)
+			}
+			if wantEdit := len(tt.args) == 0 && !tt.piped; edited != wantEdit {
+				t.Errorf("Expected the editor to be opened %t, got %t", wantEdit, edited)
+			}
+		})
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		visual   string
+		editor   string
+		expected []string
+	}{
+		{name: "default", expected: []string{defaultEditor}},
+		{name: "editor", editor: "nano", expected: []string{"nano"}},
+		{name: "editor with arguments", editor: "code --wait  --new-window", expected: []string{"code", "--wait", "--new-window"}},
+		{name: "visual wins", visual: "subl -w", editor: "nano", expected: []string{"subl", "-w"}},
+		{name: "blank visual", visual: "  ", editor: "nano", expected: []string{defaultEditor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.editor)
+			if got := editorCommand(); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEditNote(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+
+	var path string
+	note, err := editNote(func(argv []string) error {
+		if !slices.Equal(argv[:2], []string{"code", "--wait"}) || len(argv) != 3 {
+			t.Errorf("Expected the editor and its arguments before the file, got %q", argv)
+		}
+		path = argv[len(argv)-1]
+		return os.WriteFile(path, []byte("- written\r\n"), 0600)
+	})
+	if err != nil {
+		t.Fatalf("Failed to edit note: %v", err)
+	}
+	if note != "- written\r\n" {
+		t.Errorf("Expected the file's content, got %q", note)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the note file to be removed, got %v", err)
+	}
+
+	errRun := errors.New("exit status 1")
+	if _, err := editNote(func([]string) error { return errRun }); !errors.Is(err, errRun) || !strings.Contains(err.Error(), `"code --wait"`) {
+		t.Errorf("Expected the editor's error naming it, got %v", err)
+	}
+}
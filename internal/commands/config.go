@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd creates the command grouping the configuration subcommands
+func newConfigCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	cmd.AddCommand(newConfigValidateCmd(a))
+	return cmd
+}
+
+// newConfigValidateCmd creates a command checking the configuration without
+// touching any note
+func newConfigValidateCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration for errors",
+		Long: `Check the configuration the capture commands would use for errors: keys
+markin doesn't know, invalid values such as positions, and templates that don't
+parse. Environment variables the note paths refer to that are not set are
+reported as warnings, since they expand to nothing.
+
+Use "markin doctor" to also check the vault and today's note.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			opts := a.loadOptions()
+			opts.Strict = true
+			cfg, err := config.Load(a.fsys, opts)
+			if err != nil {
+				return err
+			}
+			for _, name := range cfg.UnsetVariables() {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: environment variable $%s is not set\n", name)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid.")
+			return nil
+		},
+	}
+}
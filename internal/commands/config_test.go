@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestExecuteConfigValidate(t *testing.T) {
+	t.Setenv("MARKIN_TEST_UNSET", "")
+	os.Unsetenv("MARKIN_TEST_UNSET")
+
+	tests := []struct {
+		name    string
+		config  string
+		warning string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: "project_dir: \"/vault\"\ndaily_note_name: \"{{.Date}}.md\"\nsection: \"## Log\"\n",
+		},
+		{
+			name:    "unknown key",
+			config:  "project_dir: \"/vault\"\nsectoin: \"## Log\"\n",
+			wantErr: "field sectoin not found",
+		},
+		{
+			name:    "unset variable",
+			config:  "project_dir: \"/vault\"\ndaily_note_name: \"{{.Date}}.md\"\ndaily_note_path: \"$MARKIN_TEST_UNSET/daily\"\n",
+			warning: "Warning: environment variable $MARKIN_TEST_UNSET is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupEnv(t)
+			fsys := afero.NewMemMapFs()
+			writeFile(t, fsys, userConfig, tt.config)
+
+			stdout, stderr, err := runMarkin(t, fsys, "config", "validate")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to validate configuration: %v", err)
+			}
+			if !strings.Contains(stdout, "Configuration is valid.") {
+				t.Errorf("Expected the configuration to be valid, got %q", stdout)
+			}
+			if tt.warning == "" && stderr != "" || !strings.Contains(stderr, tt.warning) {
+				t.Errorf("Expected warning %q, got %q", tt.warning, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// newDoctorCmd creates a command checking that entries can be added: the
+// configuration, the vault and today's note of each entry type
+func newDoctorCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that entries can be added",
+		Long: `Check that entries can be added: the configuration loads, the environment
+variables it refers to are set, the vault exists and is writable, and for each
+entry type, today's note and whether the section the entries go under exists in
+it. Nothing is written to the notes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			r := &report{out: cmd.OutOrStdout()}
+			a.diagnose(cmd.Context(), r)
+			if r.problems > 0 {
+				return fmt.Errorf("found %d problem(s)", r.problems)
+			}
+			return nil
+		},
+	}
+}
+
+// diagnose runs the checks of markin doctor, reporting them to r
+func (a *app) diagnose(ctx context.Context, r *report) {
+	cfg, err := a.currentConfig()
+	if err != nil {
+		r.fail("Configuration: %v", err)
+		return
+	}
+	r.ok("Configuration loaded")
+
+	for _, name := range cfg.UnsetVariables() {
+		r.fail("Environment variable $%s is not set", name)
+	}
+
+	if cfg.Storage.IsWebDAV() {
+		r.ok("Vault on WebDAV at %s, checked through today's notes", cfg.Storage.URL)
+	} else if !a.checkVault(r, cfg.VaultDir()) {
+		return
+	}
+
+	store := newStore(a.fsys, cfg, a.debug)
+	now := time.Now()
+	for _, et := range cfg.Types() {
+		target, path, err := resolveTarget(a.fsys, cfg, et, "", "", now, a.debug)
+		if err != nil {
+			r.fail("%s: %v", et.Name, err)
+			continue
+		}
+
+		content, err := store.ReadFile(ctx, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			r.ok("%s: today's note %s doesn't exist yet and will be created", et.Name, path)
+			if target.Template != "" {
+				if _, err := store.ReadFile(ctx, target.Template); err != nil {
+					r.fail("%s: template %s: %v", et.Name, target.Template, err)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			r.fail("%s: failed to read today's note %s: %v", et.Name, path, err)
+			continue
+		}
+
+		doc := markdown.Parse(string(content))
+		_, found, err := doc.MatchSection(target.Section, cfg.SectionAliases[target.Section], cfg.SectionMatch)
+		switch {
+		case err != nil:
+			r.fail("%s: %v", et.Name, err)
+		case found:
+			r.ok("%s: section %q found in %s", et.Name, target.Section, path)
+		case cfg.CreateSectionIfMissing:
+			r.warn("%s: section %q is not in %s and will be added to it", et.Name, target.Section, path)
+		default:
+			r.fail("%s: section %q is not in %s and create_section_if_missing is off", et.Name, target.Section, path)
+		}
+	}
+}
+
+// checkVault reports whether the vault directory exists and is writable
+func (a *app) checkVault(r *report, dir string) bool {
+	if dir == "" {
+		r.fail("Vault: project_dir is empty")
+		return false
+	}
+	info, err := a.fsys.Stat(dir)
+	if err != nil {
+		r.fail("Vault %s: %v", dir, err)
+		return false
+	}
+	if !info.IsDir() {
+		r.fail("Vault %s is not a directory", dir)
+		return false
+	}
+
+	f, err := afero.TempFile(a.fsys, dir, ".markin-doctor-*")
+	if err != nil {
+		r.fail("Vault %s is not writable: %v", dir, err)
+		return false
+	}
+	f.Close()
+	a.fsys.Remove(f.Name())
+
+	if config.IsVault(a.fsys, dir) {
+		r.ok("Obsidian vault %s exists and is writable", dir)
+	} else {
+		r.ok("Notes directory %s exists and is writable", dir)
+	}
+	return true
+}
+
+// report prints the outcome of markin doctor's checks and counts the problems
+type report struct {
+	out      io.Writer
+	problems int
+}
+
+func (r *report) ok(format string, args ...any) {
+	fmt.Fprintf(r.out, "✓ "+format+"\n", args...)
+}
+
+func (r *report) warn(format string, args ...any) {
+	fmt.Fprintf(r.out, "! "+format+"\n", args...)
+}
+
+func (r *report) fail(format string, args ...any) {
+	r.problems++
+	fmt.Fprintf(r.out, "✗ "+format+"\n", args...)
+}
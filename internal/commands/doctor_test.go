@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestExecuteDoctor(t *testing.T) {
+	const cfg = `project_dir: "/vault"
+daily_note_path: "daily"
+daily_note_name: "{{.Date}}.md"
+section: "## Log"
+`
+	note := filepath.Join("/vault/daily", time.Now().Format("2006-01-02")+".md")
+
+	tests := []struct {
+		name     string
+		config   string
+		note     string
+		noVault  bool
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "missing vault",
+			config:   cfg,
+			noVault:  true,
+			expected: "✗ Vault /vault:",
+			wantErr:  true,
+		},
+		{
+			name:     "section found",
+			config:   cfg,
+			note:     "# Today\n\n## Log\n- a\n",
+			expected: `✓ fl: section "## Log" found in ` + note,
+		},
+		{
+			name:     "missing section created",
+			config:   cfg + "create_section_if_missing: true\n",
+			note:     "# Today\n",
+			expected: `! fl: section "## Log" is not in ` + note + " and will be added to it",
+		},
+		{
+			name:     "missing section not created",
+			config:   cfg + "create_section_if_missing: false\n",
+			note:     "# Today\n",
+			expected: `✗ fl: section "## Log" is not in ` + note + " and create_section_if_missing is off",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupEnv(t)
+			fsys := afero.NewMemMapFs()
+			writeFile(t, fsys, userConfig, tt.config)
+			if !tt.noVault {
+				if err := fsys.MkdirAll("/vault/daily", 0755); err != nil {
+					t.Fatalf("Failed to create vault: %v", err)
+				}
+			}
+			if tt.note != "" {
+				writeFile(t, fsys, note, tt.note)
+			}
+
+			stdout, _, err := runMarkin(t, fsys, "doctor")
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Expected error %t, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(stdout, tt.expected) {
+				t.Errorf("Expected %q in the report, got:\n%s", tt.expected, stdout)
+			}
+			if tt.note != "" {
+				if content, _ := afero.ReadFile(fsys, note); string(content) != tt.note {
+					t.Errorf("Expected the note to be left untouched, got %q", content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ConfigFlags adds the global flags selecting and overriding the configuration to
+// flags, and returns the overrides they are bound to along with their MARKIN_*
+// environment variables. Flags win over environment variables, which win over the
+// configuration files.
+func ConfigFlags(flags *pflag.FlagSet) *viper.Viper {
+	positions := make([]string, len(markdown.Positions))
+	for i, p := range markdown.Positions {
+		positions[i] = string(p)
+	}
+
+	flags.String("config", "", "Configuration file to use instead of the user and project configuration ($MARKIN_CONFIG)")
+	flags.String("vault", "", "Vault directory, overriding project_dir ($MARKIN_VAULT)")
+	flags.String("section", "", "Section the entry goes to, overriding the configured sections ($MARKIN_SECTION)")
+	flags.String("position", "", "Where the entry goes in the section, overriding the configured positions: "+strings.Join(positions, ", ")+" ($MARKIN_POSITION)")
+	flags.Bool("create-section", false, "Create the section when the note lacks it ($MARKIN_CREATE_SECTION_IF_MISSING)")
+
+	v := config.NewOverrides()
+	v.BindPFlag(config.KeyConfig, flags.Lookup("config"))
+	for key, name := range config.OverrideFlags {
+		v.BindPFlag(key, flags.Lookup(name))
+	}
+	return v
+}
+
+// ParseConfigFlags reads the global configuration flags from args ahead of the
+// rest of the command line, since the configuration declares the entry commands
+// the command line is parsed with
+func ParseConfigFlags(flags *pflag.FlagSet, args []string) {
+	early := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	early.ParseErrorsWhitelist.UnknownFlags = true
+	early.Usage = func() {}
+	early.AddFlag(flags.Lookup("config"))
+	for _, name := range config.OverrideFlags {
+		early.AddFlag(flags.Lookup(name))
+	}
+	// Errors are reported when the full command line is parsed
+	early.Parse(args)
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+)
+
+func TestConfigFlags(t *testing.T) {
+	const content = `project_dir: "/vault"
+daily_note_name: "{{.Date}}.md"
+section: "## Log"
+position: "after-heading"
+periodic_notes:
+  weekly:
+    section: "## Goals"
+entry_types:
+  - name: fl
+  - name: todo
+    section: "## Tasks"
+    position: "sorted"
+`
+	tests := []struct {
+		name     string
+		env      map[string]string
+		flags    []string
+		vault    string
+		section  string
+		position string
+		create   bool
+	}{
+		{
+			name:     "files",
+			vault:    "/vault",
+			section:  "## Tasks",
+			position: "sorted",
+		},
+		{
+			name:     "environment",
+			env:      map[string]string{"MARKIN_VAULT": "/other", "MARKIN_SECTION": "## Env", "MARKIN_CREATE_SECTION_IF_MISSING": "true"},
+			vault:    "/other",
+			section:  "## Env",
+			position: "sorted",
+			create:   true,
+		},
+		{
+			name:     "project dir environment variable",
+			env:      map[string]string{"MARKIN_PROJECT_DIR": "/other"},
+			vault:    "/other",
+			section:  "## Tasks",
+			position: "sorted",
+		},
+		{
+			name:     "flags win over environment",
+			env:      map[string]string{"MARKIN_PROJECT_DIR": "/other", "MARKIN_SECTION": "## Env"},
+			flags:    []string{"--vault", "/flag", "--section", "## Flag", "--position", "before-end", "--create-section"},
+			vault:    "/flag",
+			section:  "## Flag",
+			position: "before-end",
+			create:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			flags := pflag.NewFlagSet("markin", pflag.ContinueOnError)
+			overrides := ConfigFlags(flags)
+			// The flags are scanned ahead of the entry command and its own flags
+			args := append([]string{"fl", "--config", "/config/.markin.yaml", "--tag", "x"}, tt.flags...)
+			ParseConfigFlags(flags, append(args, "a note"))
+
+			fsys := afero.NewMemMapFs()
+			if err := afero.WriteFile(fsys, "/config/.markin.yaml", []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			cfg, err := config.Load(fsys, config.LoadOptions{Path: overrides.GetString(config.KeyConfig), Overrides: overrides})
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+
+			if cfg.ProjectDir != tt.vault {
+				t.Errorf("Expected project_dir %q, got %q", tt.vault, cfg.ProjectDir)
+			}
+			if cfg.CreateSectionIfMissing != tt.create {
+				t.Errorf("Expected create_section_if_missing %v, got %v", tt.create, cfg.CreateSectionIfMissing)
+			}
+			todo := cfg.Types()[1]
+			if todo.Section != tt.section {
+				t.Errorf("Expected todo section %q, got %q", tt.section, todo.Section)
+			}
+			if todo.Position != tt.position {
+				t.Errorf("Expected todo position %q, got %q", tt.position, todo.Position)
+			}
+			// Overridden sections also replace the periodic notes' sections
+			weekly, err := cfg.Target(config.EntryType{Name: "fl"}, config.PeriodWeekly)
+			if err != nil {
+				t.Fatalf("Failed to resolve target: %v", err)
+			}
+			if tt.env["MARKIN_SECTION"] != "" && weekly.Section == "## Goals" {
+				t.Errorf("Expected the weekly section to be overridden, got %q", weekly.Section)
+			}
+		})
+	}
+}
+
+func TestConfigFlagsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		flags   []string
+		wantErr string
+	}{
+		{
+			name:    "flag",
+			flags:   []string{"--position", "bogus"},
+			wantErr: `invalid --position: position: invalid value "bogus"`,
+		},
+		{
+			name:    "environment variable",
+			env:     map[string]string{"MARKIN_TIMEZONE": "Mars/X"},
+			wantErr: "invalid $MARKIN_TIMEZONE: timezone:",
+		},
+		{
+			name:    "flag over valid environment variable",
+			env:     map[string]string{"MARKIN_POSITION": "sorted"},
+			flags:   []string{"--position", "bogus"},
+			wantErr: "invalid --position:",
+		},
+		{
+			name:    "environment variable under a valid flag",
+			env:     map[string]string{"MARKIN_POSITION": "bogus"},
+			flags:   []string{"--position", "sorted"},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			flags := pflag.NewFlagSet("markin", pflag.ContinueOnError)
+			overrides := ConfigFlags(flags)
+			ParseConfigFlags(flags, append([]string{"fl"}, tt.flags...))
+
+			fsys := afero.NewMemMapFs()
+			if err := afero.WriteFile(fsys, "/config/.markin.yaml", []byte("daily_note_name: \"{{.Date}}.md\"\n"), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			_, err := config.Load(fsys, config.LoadOptions{Path: "/config/.markin.yaml", Overrides: overrides})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected the flag to win over the environment, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if err != nil && strings.Contains(err.Error(), "configuration file") {
+				t.Errorf("Expected the error not to blame the configuration file, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// vaultSearchDepth is how many levels below the search directories vaults are looked for
+const vaultSearchDepth = 2
+
+// newInitCmd creates a command for initializing the configuration. It runs
+// without a configuration, since creating one is what it is for.
+func newInitCmd(a *app) *cobra.Command {
+	var force, nonInteractive, print bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize the configuration file",
+		Long: `Initialize the configuration file, $XDG_CONFIG_HOME/markin/.markin.yaml
+(~/.config/markin/.markin.yaml by default) unless --config says otherwise. A
+--config ending in .toml or .json is written in that format.
+
+Obsidian vaults are looked for in your home directory, ~/Documents, ~/Obsidian,
+~/Dropbox and iCloud, and you pick the one entries go to. The daily note folder,
+name format and template are read from the vault's settings, and you are asked
+for the sections entries and todos go under. The configuration is previewed
+before it is written.
+
+--vault and --section answer the questions up front. With --non-interactive the
+first vault found and the default sections are used without asking.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			configPath := a.overrides.GetString(config.KeyConfig)
+			if configPath == "" {
+				var err error
+				if configPath, err = config.UserConfigPath(); err != nil {
+					return err
+				}
+			}
+			// Fail before asking anything
+			if _, err := a.fsys.Stat(configPath); err == nil && !force && !print {
+				return fmt.Errorf("configuration file already exists at %s, use --force to replace it", configPath)
+			}
+
+			w := &wizard{
+				in:          bufio.NewReader(cmd.InOrStdin()),
+				out:         cmd.ErrOrStderr(),
+				interactive: !nonInteractive,
+			}
+			answers, err := a.initAnswers(w)
+			if err != nil {
+				return err
+			}
+			data, err := config.RenderConfigFile(configPath, answers)
+			if err != nil {
+				return err
+			}
+
+			if print {
+				_, err := cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if w.interactive {
+				fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", data)
+				if !w.confirm(fmt.Sprintf("Write this configuration to %s?", configPath)) {
+					fmt.Fprintln(w.out, "Nothing written.")
+					return nil
+				}
+			}
+			if err := config.WriteConfig(a.fsys, configPath, data, force); err != nil {
+				return fmt.Errorf("failed to write configuration: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Configuration file created at %s\n", configPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing configuration file")
+	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Use the flags and defaults without asking")
+	cmd.Flags().BoolVar(&print, "print", false, "Print the configuration instead of writing it")
+	return cmd
+}
+
+// initAnswers returns the settings the configuration is written with: the vault
+// and section flags, or what the user picks and types in w
+func (a *app) initAnswers(w *wizard) (config.InitAnswers, error) {
+	answers := config.DefaultInitAnswers()
+	home, _ := os.UserHomeDir()
+
+	vault := a.overrides.GetString("project_dir")
+	if vault == "" {
+		vaults := config.FindVaults(a.fsys, config.VaultSearchDirs(home), vaultSearchDepth)
+		var err error
+		if vault, err = w.chooseVault(vaults, home, answers.ProjectDir); err != nil {
+			return config.InitAnswers{}, err
+		}
+	}
+	answers.ProjectDir = tildePath(vault, home)
+	if err := answers.InferDailyNotes(a.fsys); err != nil {
+		return config.InitAnswers{}, fmt.Errorf("failed to read the vault's daily note settings: %w", err)
+	}
+	w.say("Daily notes: %s", filepath.Join(answers.ProjectDir, answers.DailyNotePath, answers.DailyNoteName))
+
+	if section := a.overrides.GetString("section"); section != "" {
+		answers.Section = section
+	} else {
+		answers.Section = w.ask("Section entries go under", answers.Section)
+	}
+	answers.TaskSection = w.ask("Section todos go under", answers.TaskSection)
+	return answers, nil
+}
+
+// wizard asks the questions of markin init, answering them with their defaults
+// when not interactive
+type wizard struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+}
+
+// say prints a line when interactive
+func (w *wizard) say(format string, args ...any) {
+	if w.interactive {
+		fmt.Fprintf(w.out, format+"\n", args...)
+	}
+}
+
+// ask prompts for a value, returning def when the answer is empty
+func (w *wizard) ask(prompt, def string) string {
+	if !w.interactive {
+		return def
+	}
+	fmt.Fprintf(w.out, "%s [%s]: ", prompt, def)
+	// A closed input answers with the default
+	line, _ := w.in.ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// confirm asks a yes or no question, defaulting to yes
+func (w *wizard) confirm(prompt string) bool {
+	answer := strings.ToLower(w.ask(prompt+" (y/n)", "y"))
+	return answer == "y" || answer == "yes"
+}
+
+// chooseVault lets the user pick one of vaults by number or type another
+// directory. Without vaults it asks for the notes directory, defaulting to def.
+func (w *wizard) chooseVault(vaults []string, home, def string) (string, error) {
+	if !w.interactive {
+		if len(vaults) == 0 {
+			return "", errors.New("no Obsidian vault found, give the notes directory with --vault")
+		}
+		return vaults[0], nil
+	}
+	if len(vaults) == 0 {
+		w.say("No Obsidian vault found.")
+		return w.ask("Notes directory", def), nil
+	}
+
+	w.say("Obsidian vaults found:")
+	for i, vault := range vaults {
+		w.say("  %d) %s", i+1, tildePath(vault, home))
+	}
+	for {
+		answer := w.ask("Vault, by number or path", "1")
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return answer, nil
+		}
+		if n >= 1 && n <= len(vaults) {
+			return vaults[n-1], nil
+		}
+		w.say("Pick a number between 1 and %d.", len(vaults))
+	}
+}
+
+// tildePath returns path with the home directory replaced by ~, so the
+// configuration reads the same on other machines
+func tildePath(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if rel == "." {
+			return "~"
+		}
+		return "~/" + filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultEditor is the editor used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// errEmptyNote is returned when no note text was given
+var errEmptyNote = errors.New("note is empty, nothing to add")
+
+// readNote returns the note text: the arguments joined by spaces, stdin when it
+// is piped, or what edit returns otherwise
+func readNote(args []string, stdin io.Reader, piped bool, edit func() (string, error)) (string, error) {
+	var note string
+	switch {
+	case len(args) > 0:
+		note = strings.Join(args, " ")
+	case piped:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read note from stdin: %w", err)
+		}
+		note = string(data)
+	default:
+		var err error
+		if note, err = edit(); err != nil {
+			return "", err
+		}
+	}
+
+	note = strings.TrimSpace(strings.ReplaceAll(note, "\r\n", "\n"))
+	if note == "" {
+		return "", errEmptyNote
+	}
+	return note, nil
+}
+
+// isPiped reports whether f is a pipe or a file rather than a terminal
+func isPiped(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// editorCommand returns $VISUAL or $EDITOR split into the command and its
+// arguments, e.g. "code --wait", or defaultEditor when neither is set
+func editorCommand() []string {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if fields := strings.Fields(editor); len(fields) > 0 {
+		return fields
+	}
+	return []string{defaultEditor}
+}
+
+// editNote has run open the editor command on a temporary file and returns the
+// file's content once it returns
+func editNote(run func(argv []string) error) (string, error) {
+	f, err := os.CreateTemp("", "markin-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create note file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	argv := append(editorCommand(), path)
+	if err := run(argv); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", strings.Join(argv[:len(argv)-1], " "), err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read note file: %w", err)
+	}
+	return string(data), nil
+}
+
+// runEditor runs the editor command argv on the terminal
+func runEditor(argv []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
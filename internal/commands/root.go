@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/carlisia/markin/internal/config"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// annotationConfig marks the commands that need a configuration to run
+const annotationConfig = "markin/config"
+
+// app is the state shared by the commands. The configuration is loaded in the
+// root command's PersistentPreRunE, once the command line is parsed, and only for
+// the commands that need it.
+type app struct {
+	fsys      afero.Fs
+	overrides *viper.Viper
+	debug     bool
+	cfg       *config.Config
+	// scanErr is why the configuration declaring the entry commands failed to load
+	scanErr error
+}
+
+// Execute runs the markin command line args against the notes in fsys
+func Execute(fsys afero.Fs, args []string) error {
+	a := &app{fsys: fsys}
+	rootCmd := a.rootCmd(args)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil && a.scanErr != nil && strings.HasPrefix(err.Error(), "unknown command") {
+		// The command may be an entry type of the configuration that failed to load
+		return fmt.Errorf("%w\nThe configuration declaring the entry commands failed to load: %v", err, a.scanErr)
+	}
+	return err
+}
+
+// rootCmd creates the markin command. args are scanned for the configuration
+// flags up front, since the configuration declares the entry commands. The
+// configuration loaded then is kept for the command that runs; one that fails to
+// load leaves the built-in entry types, and is loaded again to report the error
+// when a command needing it runs.
+func (a *app) rootCmd(args []string) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "markin",
+		Short: "A CLI tool for managing markdown notes",
+		Long: `Markin is a CLI tool for managing markdown notes.
+It provides commands for adding different types of notes to markdown files.`,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Annotations[annotationConfig] == "" {
+				return nil
+			}
+			cfg, err := a.currentConfig()
+			if err != nil {
+				cmd.SilenceUsage = true
+				if errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("%w\nRun 'markin init' to create one", err)
+				}
+				return err
+			}
+			a.cfg = cfg
+			return nil
+		},
+	}
+
+	rootCmd.PersistentFlags().BoolVarP(&a.debug, "debug", "d", false, "Enable debug output")
+	a.overrides = ConfigFlags(rootCmd.PersistentFlags())
+	ParseConfigFlags(rootCmd.PersistentFlags(), args)
+
+	types := (&config.Config{}).Types()
+	if cfg, err := a.loadConfig(); err != nil {
+		a.scanErr = err
+	} else {
+		a.cfg = cfg
+		types = cfg.Types()
+	}
+	for _, et := range types {
+		rootCmd.AddCommand(newEntryCmd(a, et))
+	}
+	rootCmd.AddCommand(newInitCmd(a))
+	rootCmd.AddCommand(newConfigCmd(a))
+	rootCmd.AddCommand(newDoctorCmd(a))
+	return rootCmd
+}
+
+// currentConfig returns the configuration loaded when the command line was
+// scanned, loading it again when that failed
+func (a *app) currentConfig() (*config.Config, error) {
+	if a.cfg != nil {
+		return a.cfg, nil
+	}
+	return a.loadConfig()
+}
+
+// loadConfig loads the configuration selected and overridden by the flags and
+// the MARKIN_* environment variables
+func (a *app) loadConfig() (*config.Config, error) {
+	return config.Load(a.fsys, a.loadOptions())
+}
+
+// loadOptions returns the configuration files and overrides selected by the flags
+// and the MARKIN_* environment variables, with the project configuration looked
+// up from the working directory and its parents
+func (a *app) loadOptions() config.LoadOptions {
+	dir, _ := os.Getwd()
+	return config.LoadOptions{
+		Path:      a.overrides.GetString(config.KeyConfig),
+		Dir:       dir,
+		Overrides: a.overrides,
+	}
+}
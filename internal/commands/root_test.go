@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// userConfig is where the user configuration lives in the tests' environment
+const userConfig = "/config/markin/.markin.yaml"
+
+// setupEnv points the home and configuration directories into the test
+// filesystem, clear of the real user configuration
+func setupEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	for _, key := range []string{"MARKIN_CONFIG", "MARKIN_VAULT", "MARKIN_SECTION", "MARKIN_POSITION", "MARKIN_CREATE_SECTION_IF_MISSING"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// captureOutput redirects *f into a pipe until the returned function is called,
+// which restores it and returns what was written
+func captureOutput(t *testing.T, f **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	orig := *f
+	*f = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	return func() string {
+		w.Close()
+		*f = orig
+		return <-done
+	}
+}
+
+// runMarkin runs the markin command line args against fsys, returning what it
+// wrote to stdout and stderr
+func runMarkin(t *testing.T, fsys afero.Fs, args ...string) (string, string, error) {
+	t.Helper()
+	stdout := captureOutput(t, &os.Stdout)
+	stderr := captureOutput(t, &os.Stderr)
+	err := Execute(fsys, args)
+	return stdout(), stderr(), err
+}
+
+// writeFile writes content to path in fsys
+func writeFile(t *testing.T, fsys afero.Fs, path, content string) {
+	t.Helper()
+	if err := afero.WriteFile(fsys, path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestExecuteInitWithoutConfig(t *testing.T) {
+	setupEnv(t)
+	fsys := afero.NewMemMapFs()
+
+	stdout, _, err := runMarkin(t, fsys, "init", "--non-interactive", "--vault", "/home/me/Notes")
+	if err != nil {
+		t.Fatalf("Failed to run init: %v", err)
+	}
+	if !strings.Contains(stdout, "Configuration file created at "+userConfig) {
+		t.Errorf("Expected the configuration to be created, got %q", stdout)
+	}
+	data, err := afero.ReadFile(fsys, userConfig)
+	if err != nil {
+		t.Fatalf("Failed to read configuration: %v", err)
+	}
+	if !strings.Contains(string(data), `project_dir: "~/Notes"`) {
+		t.Errorf("Expected the vault in the configuration, got:\n%s", data)
+	}
+}
+
+func TestExecuteHelpWithoutConfig(t *testing.T) {
+	setupEnv(t)
+
+	for _, args := range [][]string{{"--help"}, {"init", "--help"}, {"fl", "--help"}} {
+		stdout, _, err := runMarkin(t, afero.NewMemMapFs(), args...)
+		if err != nil {
+			t.Fatalf("Failed to run %v: %v", args, err)
+		}
+		if !strings.Contains(stdout, "Usage:") {
+			t.Errorf("Expected usage for %v, got %q", args, stdout)
+		}
+	}
+}
+
+func TestExecuteEntryWithoutConfig(t *testing.T) {
+	setupEnv(t)
+
+	_, _, err := runMarkin(t, afero.NewMemMapFs(), "fl", "hello")
+	if err == nil || !strings.Contains(err.Error(), "markin init") {
+		t.Errorf("Expected a hint to run markin init, got %v", err)
+	}
+}
+
+func TestExecuteDebug(t *testing.T) {
+	setupEnv(t)
+	fsys := afero.NewMemMapFs()
+	writeFile(t, fsys, userConfig, `project_dir: "/vault"
+daily_note_path: "daily"
+daily_note_name: "{{.Date}}.md"
+section: "## Log"
+create_section_if_missing: true
+`)
+
+	for _, debug := range []bool{false, true} {
+		args := []string{"fl", "hello"}
+		if debug {
+			args = append([]string{"--debug"}, args...)
+		}
+		stdout, _, err := runMarkin(t, fsys, args...)
+		if err != nil {
+			t.Fatalf("Failed to run %v: %v", args, err)
+		}
+		// The debug output of pkg/markdown
+		if got := strings.Contains(stdout, "Debug: Writing content to"); got != debug {
+			t.Errorf("With debug %t expected debug output %t, got %q", debug, debug, stdout)
+		}
+	}
+
+	note := filepath.Join("/vault/daily", time.Now().Format("2006-01-02")+".md")
+	content, err := afero.ReadFile(fsys, note)
+	if err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+	if n := strings.Count(string(content), "hello"); n != 2 {
+		t.Errorf("Expected 2 entries in %s, got:\n%s", note, content)
+	}
+}
+
+func TestExecuteInitTOML(t *testing.T) {
+	setupEnv(t)
+	fsys := afero.NewMemMapFs()
+	const path = "/config/markin/.markin.toml"
+
+	if _, _, err := runMarkin(t, fsys, "init", "--non-interactive", "--vault", "/vault", "--config", path); err != nil {
+		t.Fatalf("Failed to run init: %v", err)
+	}
+	data, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		t.Fatalf("Failed to read configuration: %v", err)
+	}
+	if !strings.Contains(string(data), "project_dir = '/vault'") {
+		t.Errorf("Expected a TOML configuration, got:\n%s", data)
+	}
+	if _, _, err := runMarkin(t, fsys, "config", "validate"); err != nil {
+		t.Errorf("Expected the written configuration to be valid, got %v", err)
+	}
+}
+
+// countingFs counts how many times each file is opened
+type countingFs struct {
+	afero.Fs
+	opens map[string]int
+}
+
+func (c *countingFs) Open(name string) (afero.File, error) {
+	c.opens[name]++
+	return c.Fs.Open(name)
+}
+
+func TestExecuteLoadsConfigOnce(t *testing.T) {
+	setupEnv(t)
+
+	for _, args := range [][]string{{"fl", "hello"}, {"doctor"}, {"--help"}} {
+		fsys := &countingFs{Fs: afero.NewMemMapFs(), opens: make(map[string]int)}
+		writeFile(t, fsys, userConfig, "project_dir: \"/vault\"\ndaily_note_name: \"{{.Date}}.md\"\ncreate_section_if_missing: true\n")
+		if err := fsys.MkdirAll("/vault", 0755); err != nil {
+			t.Fatalf("Failed to create vault: %v", err)
+		}
+
+		if _, _, err := runMarkin(t, fsys, args...); err != nil {
+			t.Fatalf("Failed to run %v: %v", args, err)
+		}
+		if n := fsys.opens[userConfig]; n != 1 {
+			t.Errorf("Expected %v to read the configuration once, read it %d times", args, n)
+		}
+	}
+}
@@ -1,101 +1,449 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/carlisia/markin/internal/entry"
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	ProjectDir             string `yaml:"project_dir"`
-	DailyNotePath          string `yaml:"daily_note_path"`
-	DailyNoteName          string `yaml:"daily_note_name"`
-	Section                string `yaml:"section"`
-	Position               string `yaml:"position"`
-	CreateSectionIfMissing bool   `yaml:"create_section_if_missing"`
-}
-
-// LoadConfig loads the configuration from a YAML file
-func LoadConfig(configPath string) (*Config, error) {
-	// Get the home directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find home directory: %w", err)
+	ProjectDir    string  `yaml:"project_dir"`
+	Storage       Storage `yaml:"storage"`
+	DailyNotePath string  `yaml:"daily_note_path"`
+	DailyNoteName string  `yaml:"daily_note_name"`
+	Template      string  `yaml:"template"`
+	Section       string  `yaml:"section"`
+	// SectionMatch is how sections are found in a note, one of markdown.MatchModes.
+	// In regex mode only the aliases are regular expressions, so every section
+	// entries go to must have aliases, the section itself being matched exactly.
+	SectionMatch           string                  `yaml:"section_match"`
+	SectionAliases         map[string][]string     `yaml:"section_aliases"`
+	Position               string                  `yaml:"position"`
+	Subheading             string                  `yaml:"subheading"`
+	Marker                 string                  `yaml:"marker"`
+	CreateSectionIfMissing bool                    `yaml:"create_section_if_missing"`
+	SectionOrder           []string                `yaml:"section_order"`
+	ScaffoldSections       bool                    `yaml:"scaffold_sections"`
+	PeriodicNotes          map[string]PeriodicNote `yaml:"periodic_notes"`
+	Sanitize               string                  `yaml:"sanitize"`
+	EntryFormat            string                  `yaml:"entry_format"`
+	TimeFormat             string                  `yaml:"time_format"`
+	Timezone               string                  `yaml:"timezone"`
+	EntryTypes             []EntryType             `yaml:"entry_types"`
+}
+
+// EntryType represents a kind of entry that gets its own capture command,
+// such as a fleeting note, a todo or a meeting log
+type EntryType struct {
+	// Name is the subcommand name, e.g. "fl" or "todo"
+	Name string `yaml:"name"`
+	// Description is the short help text of the subcommand
+	Description string `yaml:"description"`
+	Emoji       string `yaml:"emoji"`
+	Label       string `yaml:"label"`
+	// Section, Position, Subheading and Marker override the top-level values for this type
+	Section    string `yaml:"section"`
+	Position   string `yaml:"position"`
+	Subheading string `yaml:"subheading"`
+	Marker     string `yaml:"marker"`
+	// TimeFormat is the time format of the entry timestamp, overriding time_format
+	TimeFormat string `yaml:"time_format"`
+	// EntryFormat is the entry template for this type, overriding entry_format
+	EntryFormat string `yaml:"entry_format"`
+	// Tags and Fields are default values merged with the ones given on the command line
+	Tags   []string          `yaml:"tags"`
+	Fields map[string]string `yaml:"fields"`
+	// Period is the periodic note the entries go to, e.g. "weekly" (default: daily)
+	Period string `yaml:"period"`
+}
+
+// reservedCommands are command names entry types may not use
+var reservedCommands = []string{"init", "config", "doctor", "help", "completion"}
+
+// DefaultEntryTypes returns the entry types used when the configuration declares none
+func DefaultEntryTypes() []EntryType {
+	return []EntryType{
+		{
+			Name:        "fl",
+			Description: "Add a fleeting note to your daily note",
+			Emoji:       "⚡",
+			Label:       "Fleeting",
+		},
 	}
+}
 
-	// If no config path provided, use the default location
-	if configPath == "" {
-		configPath = filepath.Join(homeDir, ".config", "markin", ".markin.yaml")
+// Types returns the configured entry types, falling back to DefaultEntryTypes.
+// Fields left blank are filled from the top-level settings.
+func (c *Config) Types() []EntryType {
+	types := c.EntryTypes
+	if len(types) == 0 {
+		types = DefaultEntryTypes()
 	}
 
-	// Read the file
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, err
+	resolved := make([]EntryType, 0, len(types))
+	for _, et := range types {
+		if et.Section == "" {
+			et.Section = c.section(et.Name, et.Period)
+		}
+		if et.Position == "" {
+			et.Position = c.Position
+		}
+		if et.Subheading == "" {
+			et.Subheading = c.Subheading
+		}
+		if et.Marker == "" {
+			et.Marker = c.Marker
+		}
+		if et.TimeFormat == "" {
+			et.TimeFormat = c.TimeFormat
+		}
+		if et.TimeFormat == "" {
+			et.TimeFormat = entry.DefaultTimeFormat
+		}
+		if et.EntryFormat == "" {
+			et.EntryFormat = c.EntryFormat
+		}
+		if et.EntryFormat == "" {
+			et.EntryFormat = entry.DefaultFormat
+		}
+		if et.Description == "" {
+			period := et.Period
+			if period == "" {
+				period = PeriodDaily
+			}
+			et.Description = fmt.Sprintf("Add a %s entry to your %s note", et.Name, period)
+		}
+		resolved = append(resolved, et)
 	}
+	return resolved
+}
 
-	// Parse the YAML
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse configuration file at %s: %w", configPath, err)
+// TemplatePath returns the full path of the template new daily notes are created
+// from, or "" when none is configured. Relative paths are relative to project_dir,
+// and the .md extension may be left out as in Obsidian's settings.
+func (c *Config) TemplatePath() string {
+	return resolveTemplate(c.ProjectDir, c.Template)
+}
+
+// resolveTemplate resolves a template path relative to the project directory
+func resolveTemplate(projectDir, template string) string {
+	if template == "" {
+		return ""
 	}
+	path := os.ExpandEnv(template)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(markdown.ExpandPath(projectDir), path)
+	}
+	if filepath.Ext(path) == "" {
+		path += ".md"
+	}
+	return path
+}
 
-	return &config, nil
+// VaultDir returns project_dir with environment variables and a leading ~ expanded
+func (c *Config) VaultDir() string {
+	return markdown.ExpandPath(c.ProjectDir)
 }
 
-// GenerateSampleConfig generates a sample configuration file
-func GenerateSampleConfig(configPath string) error {
-	// Get the home directory
-	homeDir, err := os.UserHomeDir()
+// UnsetVariables returns the environment variables the note paths refer to that
+// are not set, which would silently expand to nothing
+func (c *Config) UnsetVariables() []string {
+	paths := []string{c.ProjectDir, c.DailyNotePath, c.DailyNoteName, c.Template}
+	for _, note := range c.PeriodicNotes {
+		paths = append(paths, note.Path, note.Name, note.Template)
+	}
+
+	var unset []string
+	for _, path := range paths {
+		os.Expand(path, func(name string) string {
+			if _, ok := os.LookupEnv(name); !ok && !slices.Contains(unset, name) {
+				unset = append(unset, name)
+			}
+			return ""
+		})
+	}
+	slices.Sort(unset)
+	return unset
+}
+
+// Location returns the time zone entry timestamps are rendered in
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
 	if err != nil {
-		return fmt.Errorf("failed to find home directory: %w", err)
+		return time.Local
 	}
+	return loc
+}
 
-	// If no config path provided, use the default location
-	if configPath == "" {
-		configPath = filepath.Join(homeDir, ".config", "markin", ".markin.yaml")
+// validate checks the configuration for values that would fail at capture time
+func (c *Config) validate() error {
+	for _, key := range []string{"timezone", "entry_format", "daily_note_path", "daily_note_name", "position", "section_match", "sanitize"} {
+		if err := c.validateKey(key); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(c.DailyNoteName) == "" {
+		// The daily note would resolve to its folder
+		return errors.New("daily_note_name: is empty, set it or point project_dir at an Obsidian vault")
+	}
+	if err := c.Storage.validate(); err != nil {
+		return err
+	}
+	if err := c.validatePeriodicNotes(); err != nil {
+		return err
+	}
+	for section, aliases := range c.SectionAliases {
+		if err := markdown.ValidateAliases(c.SectionMatch, aliases); err != nil {
+			return fmt.Errorf("section_aliases[%q]: %w", section, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, et := range c.EntryTypes {
+		if et.Name == "" {
+			return fmt.Errorf("entry_types[%d]: name is required", i)
+		}
+		if strings.ContainsAny(et.Name, " \t") {
+			return fmt.Errorf("entry_types[%d]: name %q must not contain whitespace", i, et.Name)
+		}
+		if slices.Contains(reservedCommands, et.Name) {
+			return fmt.Errorf("entry_types[%d]: name %q is reserved", i, et.Name)
+		}
+		if seen[et.Name] {
+			return fmt.Errorf("entry_types[%d]: duplicate name %q", i, et.Name)
+		}
+		seen[et.Name] = true
+
+		if err := entry.Validate(et.EntryFormat); err != nil {
+			return fmt.Errorf("entry_types[%d]: entry_format: %w", i, err)
+		}
+		subheading := et.Subheading
+		if subheading == "" {
+			subheading = c.Subheading
+		}
+		if err := validatePosition(et.Position, subheading); err != nil {
+			return fmt.Errorf("entry_types[%d]: %w", i, err)
+		}
+		if et.Period != "" && !slices.Contains(Periods, et.Period) {
+			return fmt.Errorf("entry_types[%d]: period: invalid value %q, expected one of: %s", i, et.Period, strings.Join(Periods, ", "))
+		}
+	}
+	return nil
+}
+
+// validateKey checks the value of a top-level key that flags and environment
+// variables can override. Keys without constraints are always valid.
+func (c *Config) validateKey(key string) error {
+	switch key {
+	case "timezone":
+		if c.Timezone != "" {
+			if _, err := time.LoadLocation(c.Timezone); err != nil {
+				return fmt.Errorf("timezone: %w", err)
+			}
+		}
+	case "entry_format":
+		if err := entry.Validate(c.EntryFormat); err != nil {
+			return fmt.Errorf("entry_format: %w", err)
+		}
+	case "daily_note_path":
+		return validateDateTemplate(key, c.DailyNotePath)
+	case "daily_note_name":
+		return validateDateTemplate(key, c.DailyNoteName)
+	case "position":
+		return validatePosition(c.Position, c.Subheading)
+	case "section_match":
+		if !markdown.ValidMatchMode(c.SectionMatch) {
+			return fmt.Errorf("section_match: invalid value %q, expected one of: %s", c.SectionMatch, strings.Join(markdown.MatchModes, ", "))
+		}
+		for _, section := range c.sections() {
+			if err := markdown.ValidateSectionMatch(c.SectionMatch, section, c.SectionAliases[section]); err != nil {
+				return fmt.Errorf("section_match: %w", err)
+			}
+		}
+	case "sanitize":
+		if !markdown.ValidSanitizeLevel(c.Sanitize) {
+			return fmt.Errorf("sanitize: invalid value %q, expected one of: %s", c.Sanitize, strings.Join(markdown.SanitizeLevels, ", "))
+		}
 	}
+	return nil
+}
 
-	// Check if file exists
-	if _, err := os.Stat(configPath); err == nil {
-		return fmt.Errorf("configuration file already exists at %s", configPath)
+// sections returns the sections entries go to: the top-level one and those of the
+// entry types and periodic notes
+func (c *Config) sections() []string {
+	var sections []string
+	if c.Section != "" {
+		sections = append(sections, c.Section)
+	}
+	for _, et := range c.EntryTypes {
+		if et.Section != "" {
+			sections = append(sections, et.Section)
+		}
 	}
+	for _, period := range Periods {
+		if section := c.PeriodicNotes[period].Section; section != "" {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
 
-	sample := `# Markin Configuration
+// validateDateTemplate checks that the date template of the key renders
+func validateDateTemplate(key, text string) error {
+	if _, err := markdown.RenderDateTemplate(text, time.Now()); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
 
-# The root directory of your project
-project_dir: "~/Documents/notes"
+// validatePosition checks that position is supported and has what it needs
+func validatePosition(position, subheading string) error {
+	if !markdown.ValidPosition(markdown.Position(position)) {
+		names := make([]string, len(markdown.Positions))
+		for i, p := range markdown.Positions {
+			names[i] = string(p)
+		}
+		return fmt.Errorf("position: invalid value %q, expected one of: %s", position, strings.Join(names, ", "))
+	}
+	if markdown.Position(position) == markdown.PositionUnderSubheading && subheading == "" {
+		return fmt.Errorf("position: %q requires a subheading", position)
+	}
+	return nil
+}
 
-# The path to your daily notes relative to project_dir
-daily_note_path: "daily"
+// ProjectConfigName is the name of the configuration file init writes. A project
+// configuration in the working directory or one of its parents overrides the user
+// configuration for captures made from there.
+const ProjectConfigName = ".markin.yaml"
 
-# The name of your daily note file (can include date format)
-daily_note_name: "{{.Date}}.md"
+// UserConfigPath returns the path the user configuration is written to
+func UserConfigPath() (string, error) {
+	dir, err := UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ProjectConfigName), nil
+}
 
-# The section to insert lines into
-section: "## 💭 ✍️ ✨ Notes"
+// LoadOptions select the configuration files and overrides Load uses
+type LoadOptions struct {
+	// Path is the configuration file to read instead of the user and project
+	// configuration, e.g. given with --config
+	Path string
+	// Dir is the directory the project configuration is looked up from, walking up
+	// its parents, usually the working directory
+	Dir string
+	// Overrides holds the keys set by flags and MARKIN_* environment variables
+	Overrides *viper.Viper
+	// Strict rejects keys the configuration doesn't know, which are otherwise ignored
+	Strict bool
+}
 
-# Where to insert new lines in the section
-# Options: "after-heading" or "before-end"
-position: "after-heading"
+// Load loads the configuration from fsys. Each layer overrides the keys it sets in
+// the previous ones, merging mappings such as storage and periodic_notes key by
+// key: the user configuration, the nearest project configuration from opts.Dir up,
+// then the environment variables and flags of opts.Overrides. Configuration files
+// may be YAML, TOML or JSON.
+func Load(fsys afero.Fs, opts LoadOptions) (*Config, error) {
+	var paths []string
+	if opts.Path != "" {
+		paths = []string{opts.Path}
+	} else {
+		userPath, err := findUserConfig(fsys)
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{userPath}
+		if opts.Dir != "" {
+			if projectPath := findProjectConfig(fsys, opts.Dir, userPath); projectPath != "" {
+				paths = append(paths, projectPath)
+			}
+		}
+	}
 
-# Whether to create the section if it doesn't exist
-create_section_if_missing: true
-`
+	var merged map[string]any
+	var loaded []string
+	for _, path := range paths {
+		data, err := afero.ReadFile(fsys, path)
+		if errors.Is(err, fs.ErrNotExist) && opts.Path == "" {
+			// Either layer may be missing, but not both
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		layer, err := parseLayer(path, data, opts.Strict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse configuration file at %s: %w", path, err)
+		}
+		merged = mergeLayers(merged, layer)
+		loaded = append(loaded, path)
+	}
+	if len(loaded) == 0 {
+		where := paths[0]
+		if opts.Dir != "" {
+			where += " or " + filepath.Join(opts.Dir, ProjectConfigName) + " and its parent directories"
+		}
+		return nil, fmt.Errorf("no configuration file found at %s: %w", where, fs.ErrNotExist)
+	}
+
+	var config Config
+	if err := decodeLayer(merged, &config, false); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file at %s: %w", strings.Join(loaded, " and "), err)
+	}
+
+	config.applyOverrides(opts.Overrides)
+	if err := config.validateOverrides(opts.Overrides); err != nil {
+		return nil, err
+	}
 
-	// Create the config directory if it doesn't exist
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, os.ModePerm); err != nil {
-		return fmt.Errorf("failed to create configuration directory at %s: %w", configDir, err)
+	if err := config.applyObsidianSettings(fsys); err != nil {
+		return nil, fmt.Errorf("failed to read Obsidian settings for %s: %w", config.ProjectDir, err)
 	}
 
-	// Write the sample config
-	if err := os.WriteFile(configPath, []byte(sample), 0644); err != nil {
-		return fmt.Errorf("failed to write sample configuration to %s: %w", configPath, err)
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file at %s: %w", strings.Join(loaded, " and "), err)
 	}
 
+	return &config, nil
+}
+
+// decode decodes the YAML data into config, rejecting unknown keys when strict
+func decode(data []byte, config *Config, strict bool) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(strict)
+	// An empty file sets nothing
+	if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
 	return nil
 }
+
+// LoadConfig loads the configuration from a YAML, TOML or JSON file in fsys, the
+// user configuration when configPath is empty
+func LoadConfig(fsys afero.Fs, configPath string) (*Config, error) {
+	if configPath == "" {
+		var err error
+		if configPath, err = UserConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	return Load(fsys, LoadOptions{Path: configPath})
+}
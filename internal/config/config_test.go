@@ -1,16 +1,19 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/carlisia/markin/internal/entry"
+	"github.com/spf13/afero"
 )
 
 func TestLoadConfig(t *testing.T) {
-	// Create a temporary directory for testing
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, ".markin.yaml")
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
 
 	// Create a test configuration file
 	content := `project_dir: "~/Documents/notes"
@@ -20,12 +23,12 @@ section: "## 💭 ✍️ ✨ Notes"
 position: "after-heading"
 create_section_if_missing: true
 `
-	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+	if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
 		t.Fatalf("Failed to write test config: %v", err)
 	}
 
 	// Test loading the configuration
-	cfg, err := LoadConfig(configPath)
+	cfg, err := LoadConfig(fsys, configPath)
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -62,29 +65,28 @@ create_section_if_missing: true
 
 func TestLoadConfigMissingFile(t *testing.T) {
 	// Test loading a non-existent configuration file
-	_, err := LoadConfig("nonexistent.yaml")
+	_, err := LoadConfig(afero.NewMemMapFs(), "nonexistent.yaml")
 	if err == nil {
 		t.Error("Expected error when loading non-existent config file")
 	}
 }
 
 func TestGenerateSampleConfig(t *testing.T) {
-	// Create a temporary directory for testing
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, ".markin.yaml")
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
 
 	// Generate a sample configuration
-	if err := GenerateSampleConfig(configPath); err != nil {
+	if err := GenerateSampleConfig(fsys, configPath); err != nil {
 		t.Fatalf("Failed to generate sample config: %v", err)
 	}
 
 	// Verify the configuration file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	if _, err := fsys.Stat(configPath); os.IsNotExist(err) {
 		t.Error("Sample configuration file was not created")
 	}
 
 	// Read and verify the content
-	content, err := os.ReadFile(configPath)
+	content, err := afero.ReadFile(fsys, configPath)
 	if err != nil {
 		t.Fatalf("Failed to read sample config: %v", err)
 	}
@@ -107,9 +109,8 @@ func TestGenerateSampleConfig(t *testing.T) {
 }
 
 func TestGenerateSampleConfigExistingFile(t *testing.T) {
-	// Create a temporary directory for testing
-	tmpDir := t.TempDir()
-	configPath := filepath.Join(tmpDir, ".markin.yaml")
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
 
 	// Create an existing configuration file
 	content := `project_dir: "~/Documents/notes"
@@ -119,18 +120,18 @@ section: "## 💭 ✍️ ✨ Notes"
 position: "after-heading"
 create_section_if_missing: true
 `
-	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+	if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
 		t.Fatalf("Failed to write test config: %v", err)
 	}
 
 	// Try to generate a sample configuration
-	err := GenerateSampleConfig(configPath)
+	err := GenerateSampleConfig(fsys, configPath)
 	if err == nil {
 		t.Error("Expected error when generating sample config with existing file")
 	}
 
 	// Verify the content was not changed
-	updatedContent, err := os.ReadFile(configPath)
+	updatedContent, err := afero.ReadFile(fsys, configPath)
 	if err != nil {
 		t.Fatalf("Failed to read updated config: %v", err)
 	}
@@ -139,3 +140,395 @@ create_section_if_missing: true
 		t.Error("Sample config overwrote existing configuration")
 	}
 }
+
+func TestLoadConfigEntryTypes(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
+
+	content := `project_dir: "~/Documents/notes"
+daily_note_path: "daily"
+daily_note_name: "test.md"
+section: "## 💭 ✍️ ✨ Notes"
+position: "after-heading"
+entry_types:
+  - name: todo
+    emoji: "☑️"
+    label: "Todo"
+    section: "## ✅ Tasks"
+    position: "before-end"
+    time_format: "15:04"
+  - name: idea
+    emoji: "💡"
+    label: "Idea"
+`
+	if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(fsys, configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	types := cfg.Types()
+	if len(types) != 2 {
+		t.Fatalf("Expected 2 entry types, got %d", len(types))
+	}
+
+	todo := types[0]
+	if todo.Name != "todo" || todo.Section != "## ✅ Tasks" || todo.Position != "before-end" || todo.TimeFormat != "15:04" {
+		t.Errorf("Unexpected todo entry type: %+v", todo)
+	}
+
+	// Unset fields fall back to the top-level settings
+	idea := types[1]
+	if idea.Section != cfg.Section {
+		t.Errorf("Expected idea section %s, got %s", cfg.Section, idea.Section)
+	}
+	if idea.Position != cfg.Position {
+		t.Errorf("Expected idea position %s, got %s", cfg.Position, idea.Position)
+	}
+	if idea.TimeFormat != entry.DefaultTimeFormat {
+		t.Errorf("Expected idea format %s, got %s", entry.DefaultTimeFormat, idea.TimeFormat)
+	}
+}
+
+func TestTypesDefault(t *testing.T) {
+	cfg := &Config{Section: "## Notes", Position: "after-heading"}
+
+	types := cfg.Types()
+	if len(types) != 1 || types[0].Name != "fl" {
+		t.Fatalf("Expected the default fl entry type, got %+v", types)
+	}
+	if types[0].Emoji != "⚡" || types[0].Label != "Fleeting" || types[0].Section != "## Notes" {
+		t.Errorf("Unexpected default entry type: %+v", types[0])
+	}
+}
+
+func TestLoadConfigInvalidEntryTypes(t *testing.T) {
+	tests := map[string]string{
+		"missing name": `entry_types:
+  - label: "Todo"
+`,
+		"duplicate name": `entry_types:
+  - name: todo
+  - name: todo
+`,
+		"reserved name": `entry_types:
+  - name: init
+`,
+		"whitespace in name": `entry_types:
+  - name: "my todo"
+`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			fsys := afero.NewMemMapFs()
+			configPath := "/config/.markin.yaml"
+			if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			if _, err := LoadConfig(fsys, configPath); err == nil {
+				t.Error("Expected error for invalid entry types")
+			}
+		})
+	}
+}
+
+func TestLoadConfigEntryFormat(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
+
+	content := `entry_format: "- {{.Timestamp}} {{.Text}}"
+daily_note_name: "{{.Date}}.md"
+time_format: "24h"
+timezone: "UTC"
+entry_types:
+  - name: quote
+    entry_format: "> {{.Text}} — {{.Fields.author}}"
+`
+	if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig(fsys, configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Location().String() != "UTC" {
+		t.Errorf("Expected UTC location, got %s", cfg.Location())
+	}
+
+	quote := cfg.Types()[0]
+	if quote.EntryFormat != "> {{.Text}} — {{.Fields.author}}" {
+		t.Errorf("Expected the entry type format to win, got %s", quote.EntryFormat)
+	}
+	if quote.TimeFormat != "24h" {
+		t.Errorf("Expected the top-level time format, got %s", quote.TimeFormat)
+	}
+}
+
+func TestLoadConfigInvalidFormats(t *testing.T) {
+	tests := map[string]string{
+		"unclosed action":   `entry_format: "- {{.Text"`,
+		"unknown field":     `entry_format: "- {{.Txt}}"`,
+		"unknown function":  `entry_format: "- {{shout .Text}}"`,
+		"entry type format": "entry_types:\n  - name: todo\n    entry_format: \"- {{.Nope}}\"\n",
+		"unknown timezone":  `timezone: "Mars/Olympus_Mons"`,
+		"unknown sanitize":  `sanitize: "paranoid"`,
+		"unknown match":     `section_match: "fuzzy"`,
+		"invalid alias":     "section_match: regex\nsection_aliases:\n  \"## Log\": [\"(unclosed\"]\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			fsys := afero.NewMemMapFs()
+			configPath := "/config/.markin.yaml"
+			if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			if _, err := LoadConfig(fsys, configPath); err == nil {
+				t.Error("Expected error for invalid format")
+			}
+		})
+	}
+}
+
+func TestGenerateSampleConfigLoads(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
+
+	if err := GenerateSampleConfig(fsys, configPath); err != nil {
+		t.Fatalf("Failed to generate sample config: %v", err)
+	}
+
+	// The generated sample must pass validation
+	cfg, err := LoadConfig(fsys, configPath)
+	if err != nil {
+		t.Fatalf("Failed to load sample config: %v", err)
+	}
+	if len(cfg.Types()) != 2 {
+		t.Errorf("Expected 2 entry types in the sample config, got %d", len(cfg.Types()))
+	}
+}
+
+func TestLoadConfigPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"sorted", `position: "sorted"`, true},
+		{"after last list item", `position: "after-last-list-item"`, true},
+		{"at marker", "position: \"at-marker\"\nmarker: \"%% log %%\"", true},
+		{"under subheading", "position: \"under-subheading\"\nsubheading: \"### Inbox\"", true},
+		{"under subheading without subheading", `position: "under-subheading"`, false},
+		{"unknown position", `position: "middle"`, false},
+		{"unknown entry type position", "entry_types:\n  - name: todo\n    position: \"top\"\n", false},
+		{"entry type inherits subheading", "subheading: \"Inbox\"\nentry_types:\n  - name: todo\n    position: \"under-subheading\"\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := afero.NewMemMapFs()
+			configPath := "/config/.markin.yaml"
+			content := "daily_note_name: \"{{.Date}}.md\"\n" + tt.content
+			if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			_, err := LoadConfig(fsys, configPath)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid config, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected error for invalid position")
+			}
+		})
+	}
+}
+
+func TestLoadConfigSectionMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"regex with aliases", "section: \"## Log\"\nsection_match: regex\nsection_aliases:\n  \"## Log\": [\"^#{2,3} .*Log$\"]\n", true},
+		{"regex without aliases", "section: \"## .*Log\"\nsection_match: regex\n", false},
+		{"regex without entry type aliases", "section: \"## Log\"\nsection_match: regex\nsection_aliases:\n  \"## Log\": [\"Log$\"]\nentry_types:\n  - name: todo\n    section: \"## Todo\"\n", false},
+		{"regex without periodic note aliases", "section: \"## Log\"\nsection_match: regex\nsection_aliases:\n  \"## Log\": [\"Log$\"]\nperiodic_notes:\n  weekly:\n    section: \"## Week\"\n", false},
+		{"normalized without aliases", "section: \"## Log\"\nsection_match: normalized\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := afero.NewMemMapFs()
+			configPath := "/config/.markin.yaml"
+			content := "daily_note_name: \"{{.Date}}.md\"\n" + tt.content
+			if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test config: %v", err)
+			}
+			_, err := LoadConfig(fsys, configPath)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid config, got %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "section_match")) {
+				t.Errorf("Expected a section_match error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	t.Setenv("MARKIN_TEST_VAULT", "/vault")
+	t.Setenv("HOME", "/home/me")
+
+	tests := []struct {
+		projectDir string
+		template   string
+		expected   string
+	}{
+		{"/vault", "", ""},
+		{"/vault", "Templates/Daily", "/vault/Templates/Daily.md"},
+		{"$MARKIN_TEST_VAULT", "Templates/Daily.md", "/vault/Templates/Daily.md"},
+		{"/vault", "/elsewhere/daily.md", "/elsewhere/daily.md"},
+		{"~/vault", "Templates/Daily", "/home/me/vault/Templates/Daily.md"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{ProjectDir: tt.projectDir, Template: tt.template}
+		if got := cfg.TemplatePath(); got != tt.expected {
+			t.Errorf("TemplatePath() with %q, %q = %q, expected %q", tt.projectDir, tt.template, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	fsys := afero.NewMemMapFs()
+	userPath := "/home/me/.config/markin/.markin.yaml"
+	if err := afero.WriteFile(fsys, userPath, []byte("project_dir: \"/vault\"\ndaily_note_name: \"{{.Date}}.md\"\nsection: \"## Log\"\nsection_aliases:\n  \"## Log\": [\"## Journal\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+	if err := afero.WriteFile(fsys, "/src/markin/.markin.yaml", []byte("section: \"## Markin\"\nsection_aliases:\n  \"## Markin\": [\"## CLI\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	cfg, err := Load(fsys, LoadOptions{Dir: "/src/markin"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.ProjectDir != "/vault" {
+		t.Errorf("Expected project_dir from the user config, got %q", cfg.ProjectDir)
+	}
+	if cfg.Section != "## Markin" {
+		t.Errorf("Expected section from the project config, got %q", cfg.Section)
+	}
+	if len(cfg.SectionAliases) != 2 {
+		t.Errorf("Expected the aliases of both layers, got %v", cfg.SectionAliases)
+	}
+
+	// Outside the project only the user config applies
+	if cfg, err = Load(fsys, LoadOptions{Dir: "/src/other"}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Section != "## Log" {
+		t.Errorf("Expected section from the user config, got %q", cfg.Section)
+	}
+
+	// An explicit file replaces both layers
+	if err := afero.WriteFile(fsys, "/tmp/other.yaml", []byte("daily_note_name: \"{{.Date}}.md\"\nsection: \"## Other\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if cfg, err = Load(fsys, LoadOptions{Path: "/tmp/other.yaml", Dir: "/src/markin"}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Section != "## Other" || cfg.ProjectDir != "" {
+		t.Errorf("Expected only the explicit config, got section %q and project_dir %q", cfg.Section, cfg.ProjectDir)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	fsys := afero.NewMemMapFs()
+
+	if _, err := Load(fsys, LoadOptions{Dir: "/src"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist without any config, got %v", err)
+	}
+	if err := afero.WriteFile(fsys, "/src/.markin.yaml", []byte("daily_note_name: \"{{.Date}}.md\"\nsection: \"## Log\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+	if _, err := Load(fsys, LoadOptions{Dir: "/src"}); err != nil {
+		t.Errorf("Expected the project config alone to load, got %v", err)
+	}
+	if _, err := Load(fsys, LoadOptions{Path: "/src/missing.yaml"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing explicit config, got %v", err)
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	content := "daily_note_name: \"{{.Date}}.md\"\nsection: \"## Log\"\nsectoin: \"## Typo\"\n"
+	if err := afero.WriteFile(fsys, "/config/.markin.yaml", []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	if _, err := Load(fsys, LoadOptions{Path: "/config/.markin.yaml"}); err != nil {
+		t.Errorf("Expected unknown keys to be ignored, got %v", err)
+	}
+	_, err := Load(fsys, LoadOptions{Path: "/config/.markin.yaml", Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "sectoin") {
+		t.Errorf("Expected an error naming the unknown key, got %v", err)
+	}
+
+	if err := afero.WriteFile(fsys, "/config/empty.yaml", nil, 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	// An empty config has no daily note name to resolve the daily note with
+	_, err = Load(fsys, LoadOptions{Path: "/config/empty.yaml", Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "daily_note_name") {
+		t.Errorf("Expected an empty config to fail for its daily note name, got %v", err)
+	}
+}
+
+func TestLoadConfigInvalidDateTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"daily note name", `daily_note_name: "{{.Nope}}.md"`},
+		{"daily note path", `daily_note_path: "{{.Year"`},
+		{"periodic note name", "periodic_notes:\n  weekly:\n    name: \"{{moment}}.md\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, afero.NewMemMapFs(), tt.content); err == nil {
+				t.Error("Expected error for invalid date template")
+			}
+		})
+	}
+}
+
+func TestUnsetVariables(t *testing.T) {
+	t.Setenv("MARKIN_TEST_VAULT", "/vault")
+	os.Unsetenv("MARKIN_TEST_UNSET")
+
+	cfg := &Config{
+		ProjectDir:    "$MARKIN_TEST_VAULT",
+		DailyNotePath: "${MARKIN_TEST_UNSET}/daily",
+		DailyNoteName: "{{.Date}}.md",
+		PeriodicNotes: map[string]PeriodicNote{
+			PeriodWeekly: {Path: "$MARKIN_TEST_UNSET", Template: "$MARKIN_TEST_TEMPLATES/Weekly"},
+		},
+	}
+	unset := cfg.UnsetVariables()
+	expected := []string{"MARKIN_TEST_TEMPLATES", "MARKIN_TEST_UNSET"}
+	if strings.Join(unset, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected unset variables %v, got %v", expected, unset)
+	}
+}
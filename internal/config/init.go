@@ -0,0 +1,305 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// InitAnswers are the settings markin init fills the configuration in with
+type InitAnswers struct {
+	ProjectDir    string
+	DailyNotePath string
+	DailyNoteName string
+	// Template is the daily note template, left commented out when empty
+	Template string
+	// Section is where entries go, TaskSection where todos go
+	Section     string
+	TaskSection string
+}
+
+// DefaultInitAnswers returns the settings of the sample configuration
+func DefaultInitAnswers() InitAnswers {
+	return InitAnswers{
+		ProjectDir:    "~/Documents/notes",
+		DailyNotePath: "daily",
+		DailyNoteName: "{{.Date}}.md",
+		Section:       "## 💭 ✍️ ✨ Notes",
+		TaskSection:   "## ✅ Tasks",
+	}
+}
+
+// InferDailyNotes fills the daily note folder, name and template of a from the
+// Obsidian settings of the vault at a.ProjectDir. Daily notes of a vault without
+// settings are named YYYY-MM-DD at its root, as in Obsidian.
+func (a *InitAnswers) InferDailyNotes(fsys afero.Fs) error {
+	vaultDir := markdown.ExpandPath(a.ProjectDir)
+	if !IsVault(fsys, vaultDir) {
+		return nil
+	}
+	settings, err := ReadObsidianSettings(fsys, vaultDir)
+	if err != nil {
+		return err
+	}
+
+	daily := settings.Daily
+	if daily == nil {
+		daily = &NoteSettings{}
+	}
+	a.DailyNotePath = strings.TrimPrefix(daily.Folder, "/")
+	a.DailyNoteName = daily.NameTemplate(defaultObsidianFormat)
+	a.Template = strings.TrimPrefix(daily.Template, "/")
+	return nil
+}
+
+// VaultSearchDirs returns the directories markin init looks for Obsidian vaults
+// in, given the user's home directory
+func VaultSearchDirs(home string) []string {
+	return []string{
+		home,
+		filepath.Join(home, "Documents"),
+		filepath.Join(home, "Obsidian"),
+		filepath.Join(home, "Dropbox"),
+		// Vaults synced with iCloud on macOS
+		filepath.Join(home, "Library", "Mobile Documents", "iCloud~md~obsidian", "Documents"),
+	}
+}
+
+// FindVaults returns the Obsidian vaults among dirs and their subdirectories up
+// to depth levels down. Hidden directories and the inside of vaults are skipped.
+func FindVaults(fsys afero.Fs, dirs []string, depth int) []string {
+	var vaults []string
+	seen := make(map[string]bool)
+	var find func(dir string, depth int)
+	find = func(dir string, depth int) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if IsVault(fsys, dir) {
+			vaults = append(vaults, dir)
+			return
+		}
+		if depth == 0 {
+			return
+		}
+		// Unreadable directories are skipped
+		entries, _ := afero.ReadDir(fsys, dir)
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				find(filepath.Join(dir, entry.Name()), depth-1)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		find(filepath.Clean(dir), depth)
+	}
+	return vaults
+}
+
+// RenderConfig returns the sample configuration filled in with answers
+func RenderConfig(answers InitAnswers) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := sampleConfig.Execute(&buf, answers); err != nil {
+		return nil, fmt.Errorf("failed to render configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderConfigFile returns the sample configuration filled in with answers in
+// the format of the configuration file at path. TOML and JSON lose the comments
+// documenting the settings.
+func RenderConfigFile(path string, answers InitAnswers) ([]byte, error) {
+	data, err := RenderConfig(answers)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".toml" && ext != ".json" {
+		return data, nil
+	}
+
+	var layer map[string]any
+	if err := yaml.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("failed to render configuration: %w", err)
+	}
+	if ext == ".toml" {
+		data, err = toml.Marshal(layer)
+	} else {
+		data, err = json.MarshalIndent(layer, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render configuration: %w", err)
+	}
+	return data, nil
+}
+
+// WriteConfig writes the configuration data to configPath in fsys, the user
+// configuration when empty. An existing file is only replaced when force is set.
+func WriteConfig(fsys afero.Fs, configPath string, data []byte, force bool) error {
+	// If no config path provided, use the default location
+	if configPath == "" {
+		var err error
+		if configPath, err = UserConfigPath(); err != nil {
+			return err
+		}
+	}
+
+	// Check if file exists
+	if _, err := fsys.Stat(configPath); err == nil && !force {
+		return fmt.Errorf("configuration file already exists at %s", configPath)
+	}
+
+	// Create the config directory if it doesn't exist
+	configDir := filepath.Dir(configPath)
+	if err := fsys.MkdirAll(configDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create configuration directory at %s: %w", configDir, err)
+	}
+
+	if err := afero.WriteFile(fsys, configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write configuration to %s: %w", configPath, err)
+	}
+	return nil
+}
+
+// GenerateSampleConfig generates a sample configuration file in fsys
+func GenerateSampleConfig(fsys afero.Fs, configPath string) error {
+	data, err := RenderConfigFile(configPath, DefaultInitAnswers())
+	if err != nil {
+		return err
+	}
+	return WriteConfig(fsys, configPath, data, false)
+}
+
+// sampleConfig is the documented configuration markin init writes. It uses << >>
+// delimiters, since the settings it documents are templates themselves.
+var sampleConfig = template.Must(template.New("config").
+	Delims("<<", ">>").
+	Funcs(template.FuncMap{"quote": strconv.Quote}).
+	Parse(`# Markin Configuration
+
+# The root directory of your project
+project_dir: <<quote .ProjectDir>>
+
+# Notes living on a WebDAV share such as Nextcloud instead of the local disk.
+# project_dir is then relative to the URL, and the password is read from the
+# environment variable named by password_env (default: MARKIN_WEBDAV_PASSWORD).
+# storage:
+#   backend: "webdav"
+#   url: "https://cloud.example.com/remote.php/dav/files/me"
+#   username: "me"
+#   password_env: "NEXTCLOUD_PASSWORD"
+
+# The path to your daily notes relative to project_dir
+# (can include a date template, e.g. "daily/{{.Year}}/{{.Month}}")
+daily_note_path: <<quote .DailyNotePath>>
+
+# The name of your daily note file, rendered as a date template.
+# Examples: "{{.Date}}.md", "{{moment \"YYYY-MM-DD dddd\"}}.md", "{{.Year}}-W{{week}}.md"
+daily_note_name: <<quote .DailyNoteName>>
+
+# The Obsidian template new daily notes are created from, relative to project_dir.
+# Supports {{date}}, {{time}}, {{title}}, {{date:YYYY-MM-DD}}, {{yesterday}},
+# {{tomorrow}}, {{date+1d:FORMAT}} and Templater's tp.date.now / tp.file.title.
+<<if .Template>>template: <<quote .Template>><<else>># template: "Templates/Daily"<<end>>
+
+# The section to insert lines into
+section: <<quote .Section>>
+
+# How sections are found in a note: "exact" (default) compares heading level and
+# text, "normalized" ignores level, case, emoji and whitespace, and "regex" treats
+# the aliases below as regular expressions, so each section needs some
+# section_match: "normalized"
+
+# Other headings a section may appear under in existing notes. New sections are
+# always created with the configured heading.
+# section_aliases:
+#   "## 💭 ✍️ ✨ Notes":
+#     - "## Notes"
+#     - "## Scratchpad"
+
+# Where to insert new lines in the section
+# Options: "after-heading", "before-end", "after-last-list-item", "sorted"
+# (chronologically by timestamp), "under-subheading" or "at-marker"
+position: "after-heading"
+
+# The sub-heading used by the "under-subheading" position, created if missing
+# subheading: "### Inbox"
+
+# The marker line new entries are inserted above with the "at-marker" position
+# marker: "<!-- markin:insert -->"
+
+# Whether to create the section if it doesn't exist
+create_section_if_missing: true
+
+# The order of the sections in your daily note. A missing section is created
+# in its slot between the existing ones instead of at the end of the note.
+# section_order:
+#   - "## ✅ Tasks"
+#   - "## 📝 Log"
+#   - "## 💭 ✍️ ✨ Notes"
+#   - "## 🔎 Review"
+
+# Whether to create all missing sections of section_order at once
+# scaffold_sections: false
+
+# How note text is escaped so it can't break the note: "structural" (default)
+# escapes headings, "---" lines, code fences and HTML blocks; "strict" also
+# escapes quotes, tables and list markers; "none" inserts the text as is
+# sanitize: "structural"
+
+# The template used to render entries. Available fields: .Text, .Time,
+# .Timestamp, .Type, .Label, .Emoji, .Tags, .Dir, .Host and .Fields
+entry_format: "- {{with .Emoji}}{{.}} {{end}}*{{.Timestamp}}:* {{with .Label}}**{{.}}**:: {{end}}{{.Text}}"
+
+# The timestamp format: 12h, 12h-seconds, 24h, 24h-seconds, iso8601, iso8601-utc or a Go layout
+time_format: "12h-seconds"
+
+# The time zone of entry timestamps, e.g. "UTC" or "Europe/Berlin" (default: local)
+# timezone: "Local"
+
+# Weekly, monthly, quarterly and yearly notes, used by entry types with a period
+# and by --period. Names default to the Periodic Notes formats (gggg-[W]ww,
+# YYYY-MM, YYYY-[Q]Q and YYYY), sections to the section above.
+# periodic_notes:
+#   weekly:
+#     path: "weekly"
+#     name: "{{moment \"gggg-[W]ww\"}}.md"
+#     template: "Templates/Weekly"
+#     section: "## 🎯 Goals"
+#   monthly:
+#     path: "monthly"
+#     section: "## 🔎 Review"
+
+# Entry types, one capture command each (markin fl, markin todo, ...).
+# section, position, time_format and entry_format default to the values above.
+entry_types:
+  - name: fl
+    description: "Add a fleeting note to your daily note"
+    emoji: "⚡"
+    label: "Fleeting"
+  - name: todo
+    description: "Add a todo to your daily note"
+    emoji: "☑️"
+    label: "Todo"
+    section: <<quote .TaskSection>>
+    position: "before-end"
+    time_format: "24h"
+    entry_format: "- [ ] {{.Text}} {{hashtags .Tags}}"
+    tags: ["todo"]
+  # An entry type writing to the weekly note
+  # - name: goal
+  #   label: "Goal"
+  #   period: "weekly"
+`))
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFindVaults(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	for _, dir := range []string{
+		"/home/me/Documents/Notes/.obsidian",
+		"/home/me/Documents/Notes/Inner/.obsidian",
+		"/home/me/Obsidian/.obsidian",
+		"/home/me/.hidden/Vault/.obsidian",
+		"/home/me/Projects/a/b/Deep/.obsidian",
+		"/home/me/Projects/plain",
+	} {
+		if err := fsys.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	vaults := FindVaults(fsys, VaultSearchDirs("/home/me"), 2)
+	expected := []string{"/home/me/Documents/Notes", "/home/me/Obsidian"}
+	if !slices.Equal(vaults, expected) {
+		t.Errorf("Expected vaults %v, got %v", expected, vaults)
+	}
+}
+
+func TestInferDailyNotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected InitAnswers
+	}{
+		{
+			name: "daily notes plugin",
+			files: map[string]string{
+				".obsidian/daily-notes.json": `{"folder": "/Journal", "format": "YYYY-MM-DD dddd", "template": "Templates/Daily"}`,
+			},
+			expected: InitAnswers{
+				ProjectDir:    testVault,
+				DailyNotePath: "Journal",
+				DailyNoteName: `{{moment "YYYY-MM-DD dddd"}}.md`,
+				Template:      "Templates/Daily",
+			},
+		},
+		{
+			name:  "obsidian defaults",
+			files: map[string]string{},
+			expected: InitAnswers{
+				ProjectDir:    testVault,
+				DailyNoteName: `{{moment "YYYY-MM-DD"}}.md`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := afero.NewMemMapFs()
+			answers := InitAnswers{ProjectDir: writeVault(t, fsys, tt.files)}
+			if err := answers.InferDailyNotes(fsys); err != nil {
+				t.Fatalf("Failed to infer daily notes: %v", err)
+			}
+			if answers != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, answers)
+			}
+		})
+	}
+}
+
+func TestInferDailyNotesNotVault(t *testing.T) {
+	answers := DefaultInitAnswers()
+	if err := answers.InferDailyNotes(afero.NewMemMapFs()); err != nil {
+		t.Fatalf("Failed to infer daily notes: %v", err)
+	}
+	if answers != DefaultInitAnswers() {
+		t.Errorf("Expected the defaults for a directory that isn't a vault, got %+v", answers)
+	}
+}
+
+func TestRenderConfigLoads(t *testing.T) {
+	answers := InitAnswers{
+		ProjectDir:    "/vault",
+		DailyNotePath: "Journal",
+		DailyNoteName: `{{moment "YYYY-MM-DD dddd"}}.md`,
+		Template:      "Templates/Daily",
+		Section:       `## "Log"`,
+		TaskSection:   "## Tasks",
+	}
+	data, err := RenderConfig(answers)
+	if err != nil {
+		t.Fatalf("Failed to render config: %v", err)
+	}
+
+	cfg, err := loadTestConfig(t, afero.NewMemMapFs(), string(data))
+	if err != nil {
+		t.Fatalf("Failed to load rendered config: %v", err)
+	}
+	if cfg.ProjectDir != answers.ProjectDir || cfg.DailyNotePath != answers.DailyNotePath ||
+		cfg.DailyNoteName != answers.DailyNoteName || cfg.Template != answers.Template || cfg.Section != answers.Section {
+		t.Errorf("Rendered config doesn't match the answers %+v: %+v", answers, cfg)
+	}
+	todo, err := cfg.Target(cfg.Types()[1], "")
+	if err != nil {
+		t.Fatalf("Failed to resolve todo target: %v", err)
+	}
+	if todo.Section != answers.TaskSection {
+		t.Errorf("Expected todo section %q, got %q", answers.TaskSection, todo.Section)
+	}
+}
+
+func TestRenderConfigFileFormats(t *testing.T) {
+	answers := DefaultInitAnswers()
+	answers.ProjectDir = "/vault"
+	fsys := afero.NewMemMapFs()
+
+	var expected *Config
+	for _, path := range []string{"/config/.markin.yaml", "/config/.markin.toml", "/config/.markin.json"} {
+		data, err := RenderConfigFile(path, answers)
+		if err != nil {
+			t.Fatalf("Failed to render %s: %v", path, err)
+		}
+		if err := afero.WriteFile(fsys, path, data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		cfg, err := Load(fsys, LoadOptions{Path: path, Strict: true})
+		if err != nil {
+			t.Fatalf("Failed to load rendered %s: %v\n%s", path, err, data)
+		}
+		if expected == nil {
+			expected = cfg
+		} else if !reflect.DeepEqual(cfg, expected) {
+			t.Errorf("%s doesn't load like the YAML configuration.\nExpected:\n%+v\nGot:\n%+v", path, expected, cfg)
+		}
+	}
+}
+
+func TestWriteConfigForce(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	configPath := "/config/.markin.yaml"
+	if err := WriteConfig(fsys, configPath, []byte("old"), false); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := WriteConfig(fsys, configPath, []byte("new"), false); err == nil {
+		t.Error("Expected error when writing over an existing config without force")
+	}
+	if err := WriteConfig(fsys, configPath, []byte("new"), true); err != nil {
+		t.Fatalf("Failed to write config with force: %v", err)
+	}
+	content, err := afero.ReadFile(fsys, configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if string(content) != "new" {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", "new", content)
+	}
+}
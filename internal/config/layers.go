@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigNames are the names configuration files are looked up by in a directory,
+// in order of preference. The format follows the extension.
+var ConfigNames = []string{ProjectConfigName, ".markin.yml", ".markin.toml", ".markin.json"}
+
+// UserConfigDir returns the directory of the user configuration:
+// $XDG_CONFIG_HOME/markin, or ~/.config/markin when it is not set
+func UserConfigDir() (string, error) {
+	// The XDG spec says relative paths are to be ignored
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "markin"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "markin"), nil
+}
+
+// findUserConfig returns the user configuration file in UserConfigDir, or the
+// default path when it has none
+func findUserConfig(fsys afero.Fs) (string, error) {
+	dir, err := UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if path := findConfigFile(fsys, dir); path != "" {
+		return path, nil
+	}
+	return filepath.Join(dir, ProjectConfigName), nil
+}
+
+// findConfigFile returns the configuration file in dir, or "" when it has none
+func findConfigFile(fsys afero.Fs, dir string) string {
+	for _, name := range ConfigNames {
+		path := filepath.Join(dir, name)
+		if info, err := fsys.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// findProjectConfig returns the configuration file in dir or the nearest of its
+// parent directories, skipping the user configuration at userPath
+func findProjectConfig(fsys afero.Fs, dir, userPath string) string {
+	for dir = filepath.Clean(dir); ; dir = filepath.Dir(dir) {
+		if path := findConfigFile(fsys, dir); path != "" && path != userPath {
+			return path
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// parseLayer parses a YAML, TOML or JSON configuration file into its keys. When
+// strict, keys the configuration doesn't know are rejected.
+func parseLayer(path string, data []byte, strict bool) (map[string]any, error) {
+	var layer map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		if err := toml.Unmarshal(data, &layer); err != nil {
+			return nil, err
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &layer); err != nil {
+			return nil, err
+		}
+	default:
+		// Checking the file itself keeps the line numbers of unknown keys
+		if strict {
+			if err := decode(data, &Config{}, true); err != nil {
+				return nil, err
+			}
+		}
+		if err := yaml.Unmarshal(data, &layer); err != nil {
+			return nil, err
+		}
+		return layer, nil
+	}
+
+	if strict {
+		if err := decodeLayer(layer, &Config{}, true); err != nil {
+			return nil, err
+		}
+	}
+	return layer, nil
+}
+
+// decodeLayer decodes the keys of a configuration layer into config
+func decodeLayer(layer map[string]any, config *Config, strict bool) error {
+	data, err := yaml.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	return decode(data, config, strict)
+}
+
+// mergeLayers deep-merges src into dst: mappings are merged key by key, and any
+// other value of src, lists included, replaces the one in dst
+func mergeLayers(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				dst[key] = mergeLayers(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+	return dst
+}
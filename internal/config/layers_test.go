@@ -0,0 +1,185 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeConfigs(t *testing.T, fsys afero.Fs, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		if err := afero.WriteFile(fsys, path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestUserConfigDir(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+
+	tests := []struct {
+		xdg      string
+		expected string
+	}{
+		{"", "/home/me/.config/markin"},
+		{"/xdg", "/xdg/markin"},
+		{"relative", "/home/me/.config/markin"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+		dir, err := UserConfigDir()
+		if err != nil {
+			t.Fatalf("Failed to get user config dir: %v", err)
+		}
+		if dir != tt.expected {
+			t.Errorf("UserConfigDir() with XDG_CONFIG_HOME=%q = %q, expected %q", tt.xdg, dir, tt.expected)
+		}
+	}
+}
+
+func TestLoadXDG(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	fsys := afero.NewMemMapFs()
+	writeConfigs(t, fsys, map[string]string{
+		"/home/me/.config/markin/.markin.yaml": "section: \"## Home\"\n",
+		"/xdg/markin/.markin.yaml":             "daily_note_name: \"{{.Date}}.md\"\nsection: \"## XDG\"\n",
+	})
+
+	cfg, err := Load(fsys, LoadOptions{})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Section != "## XDG" {
+		t.Errorf("Expected section from $XDG_CONFIG_HOME, got %q", cfg.Section)
+	}
+}
+
+func TestLoadWalksUp(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	fsys := afero.NewMemMapFs()
+	writeConfigs(t, fsys, map[string]string{
+		"/home/me/.config/markin/.markin.yaml": "project_dir: \"/vault\"\ndaily_note_name: \"{{.Date}}.md\"\nsection: \"## Log\"\n",
+		"/src/.markin.yaml":                    "section: \"## Src\"\n",
+		"/src/markin/.markin.yaml":             "section: \"## Markin\"\n",
+	})
+	if err := fsys.MkdirAll("/src/markin/internal/config", 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	tests := []struct {
+		dir     string
+		section string
+	}{
+		{"/src/markin/internal/config", "## Markin"},
+		{"/src/markin", "## Markin"},
+		{"/src/other", "## Src"},
+		{"/elsewhere", "## Log"},
+		// The user config directory is not a project
+		{"/home/me/.config/markin", "## Log"},
+	}
+
+	for _, tt := range tests {
+		cfg, err := Load(fsys, LoadOptions{Dir: tt.dir})
+		if err != nil {
+			t.Fatalf("Failed to load config from %s: %v", tt.dir, err)
+		}
+		if cfg.Section != tt.section || cfg.ProjectDir != "/vault" {
+			t.Errorf("From %s expected section %q in /vault, got %q in %q", tt.dir, tt.section, cfg.Section, cfg.ProjectDir)
+		}
+	}
+}
+
+func TestLoadDeepMerge(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	fsys := afero.NewMemMapFs()
+	writeConfigs(t, fsys, map[string]string{
+		"/home/me/.config/markin/.markin.yaml": `project_dir: "/vault"
+daily_note_name: "{{.Date}}.md"
+storage:
+  backend: "webdav"
+  url: "https://cloud.example.com/dav"
+  username: "me"
+periodic_notes:
+  weekly:
+    path: "weekly"
+    section: "## Goals"
+entry_types:
+  - name: fl
+  - name: todo
+`,
+		"/src/.markin.yaml": `storage:
+  username: "work"
+periodic_notes:
+  weekly:
+    section: "## Markin"
+entry_types:
+  - name: idea
+`,
+	})
+
+	cfg, err := Load(fsys, LoadOptions{Dir: "/src"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Storage.URL != "https://cloud.example.com/dav" || cfg.Storage.Username != "work" {
+		t.Errorf("Expected storage merged key by key, got %+v", cfg.Storage)
+	}
+	weekly := cfg.PeriodicNotes[PeriodWeekly]
+	if weekly.Path != "weekly" || weekly.Section != "## Markin" {
+		t.Errorf("Expected periodic notes merged key by key, got %+v", weekly)
+	}
+	if len(cfg.EntryTypes) != 1 || cfg.EntryTypes[0].Name != "idea" {
+		t.Errorf("Expected entry types replaced by the project config, got %+v", cfg.EntryTypes)
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	fsys := afero.NewMemMapFs()
+	writeConfigs(t, fsys, map[string]string{
+		"/home/me/.config/markin/.markin.toml": `project_dir = "/vault"
+daily_note_name = "{{.Date}}.md"
+create_section_if_missing = true
+
+[section_aliases]
+"## Log" = ["## Journal"]
+
+[[entry_types]]
+name = "fl"
+label = "Fleeting"
+`,
+		"/src/.markin.json": `{"section": "## Log", "periodic_notes": {"weekly": {"path": "weekly"}}}`,
+	})
+
+	cfg, err := Load(fsys, LoadOptions{Dir: "/src"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.ProjectDir != "/vault" || !cfg.CreateSectionIfMissing || cfg.Section != "## Log" {
+		t.Errorf("Expected settings of both files, got project_dir %q, create_section_if_missing %v, section %q",
+			cfg.ProjectDir, cfg.CreateSectionIfMissing, cfg.Section)
+	}
+	if aliases := cfg.SectionAliases["## Log"]; len(aliases) != 1 || aliases[0] != "## Journal" {
+		t.Errorf("Expected section aliases from TOML, got %v", cfg.SectionAliases)
+	}
+	if len(cfg.EntryTypes) != 1 || cfg.EntryTypes[0].Label != "Fleeting" {
+		t.Errorf("Expected entry types from TOML, got %+v", cfg.EntryTypes)
+	}
+	if cfg.PeriodicNotes[PeriodWeekly].Path != "weekly" {
+		t.Errorf("Expected periodic notes from JSON, got %+v", cfg.PeriodicNotes)
+	}
+
+	// Unknown keys are caught in every format
+	writeConfigs(t, fsys, map[string]string{"/src/.markin.json": `{"sectoin": "## Log"}`})
+	_, err = Load(fsys, LoadOptions{Dir: "/src", Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "sectoin") {
+		t.Errorf("Expected an error naming the unknown key, got %v", err)
+	}
+}
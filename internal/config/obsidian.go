@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
+)
+
+// defaultObsidianFormat is the daily note format Obsidian uses when none is set
+const defaultObsidianFormat = "YYYY-MM-DD"
+
+// NoteSettings are the folder, Moment.js name format and template Obsidian uses
+// for a kind of note
+type NoteSettings struct {
+	Folder   string `json:"folder"`
+	Format   string `json:"format"`
+	Template string `json:"template"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// NameTemplate returns the note name as a markin date template, e.g. {{moment "YYYY-MM-DD"}}.md
+func (s NoteSettings) NameTemplate(defaultFormat string) string {
+	format := s.Format
+	if format == "" {
+		format = defaultFormat
+	}
+	return momentName(format)
+}
+
+// momentName returns a note name template formatting the capture time with a
+// Moment.js format
+func momentName(format string) string {
+	return fmt.Sprintf("{{moment %s}}.md", strconv.Quote(format))
+}
+
+// ObsidianSettings are the note settings read from a vault's .obsidian directory
+type ObsidianSettings struct {
+	// Daily comes from the Periodic Notes plugin when it manages daily notes,
+	// and from the core Daily notes plugin otherwise
+	Daily *NoteSettings
+	// Weekly, Monthly, Quarterly and Yearly come from the Periodic Notes plugin
+	Weekly    *NoteSettings
+	Monthly   *NoteSettings
+	Quarterly *NoteSettings
+	Yearly    *NoteSettings
+}
+
+// periodicNotesData is the data.json of the Periodic Notes plugin
+type periodicNotesData struct {
+	Daily     *NoteSettings `json:"daily"`
+	Weekly    *NoteSettings `json:"weekly"`
+	Monthly   *NoteSettings `json:"monthly"`
+	Quarterly *NoteSettings `json:"quarterly"`
+	Yearly    *NoteSettings `json:"yearly"`
+}
+
+// IsVault reports whether dir is an Obsidian vault, i.e. contains a .obsidian directory
+func IsVault(fsys afero.Fs, dir string) bool {
+	info, err := fsys.Stat(filepath.Join(dir, ".obsidian"))
+	return err == nil && info.IsDir()
+}
+
+// ReadObsidianSettings reads the daily and periodic note settings of the vault at
+// vaultDir from .obsidian/daily-notes.json and the Periodic Notes plugin's data.json
+func ReadObsidianSettings(fsys afero.Fs, vaultDir string) (*ObsidianSettings, error) {
+	settings := &ObsidianSettings{}
+	obsidianDir := filepath.Join(vaultDir, ".obsidian")
+
+	var daily NoteSettings
+	found, err := readJSON(fsys, filepath.Join(obsidianDir, "daily-notes.json"), &daily)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		settings.Daily = &daily
+	}
+
+	var periodic periodicNotesData
+	found, err = readJSON(fsys, filepath.Join(obsidianDir, "plugins", "periodic-notes", "data.json"), &periodic)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if periodic.Daily != nil && periodic.Daily.Enabled {
+			settings.Daily = periodic.Daily
+		}
+		settings.Weekly = enabled(periodic.Weekly)
+		settings.Monthly = enabled(periodic.Monthly)
+		settings.Quarterly = enabled(periodic.Quarterly)
+		settings.Yearly = enabled(periodic.Yearly)
+	}
+
+	return settings, nil
+}
+
+func enabled(s *NoteSettings) *NoteSettings {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	return s
+}
+
+// readJSON decodes the JSON file at path into v, reporting false when it doesn't exist
+func readJSON(fsys afero.Fs, path string, v any) (bool, error) {
+	data, err := afero.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// applyObsidianSettings fills the daily and periodic note settings left blank from
+// the Obsidian configuration of the vault at project_dir, so markin writes to the
+// same file Obsidian opens for today
+func (c *Config) applyObsidianSettings(fsys afero.Fs) error {
+	// Vaults on WebDAV are not on the local disk to read the settings from
+	if c.ProjectDir == "" || c.Storage.IsWebDAV() {
+		return nil
+	}
+	vaultDir := markdown.ExpandPath(c.ProjectDir)
+	if !IsVault(fsys, vaultDir) {
+		return nil
+	}
+
+	settings, err := ReadObsidianSettings(fsys, vaultDir)
+	if err != nil {
+		return err
+	}
+
+	// Obsidian stores folders and templates relative to the vault, sometimes with a
+	// leading slash. Without settings, daily notes are named YYYY-MM-DD at the root.
+	daily := settings.Daily
+	if daily == nil {
+		daily = &NoteSettings{}
+	}
+	if c.DailyNotePath == "" {
+		c.DailyNotePath = strings.TrimPrefix(daily.Folder, "/")
+	}
+	if c.DailyNoteName == "" {
+		c.DailyNoteName = daily.NameTemplate(defaultObsidianFormat)
+	}
+	if c.Template == "" {
+		c.Template = strings.TrimPrefix(daily.Template, "/")
+	}
+
+	periodic := map[string]*NoteSettings{
+		PeriodWeekly:    settings.Weekly,
+		PeriodMonthly:   settings.Monthly,
+		PeriodQuarterly: settings.Quarterly,
+		PeriodYearly:    settings.Yearly,
+	}
+	for period, s := range periodic {
+		if s == nil {
+			continue
+		}
+		if c.PeriodicNotes == nil {
+			c.PeriodicNotes = make(map[string]PeriodicNote)
+		}
+		note := c.PeriodicNotes[period]
+		if note.Path == "" {
+			note.Path = strings.TrimPrefix(s.Folder, "/")
+		}
+		if note.Name == "" {
+			note.Name = s.NameTemplate(periodFormats[period])
+		}
+		if note.Template == "" {
+			note.Template = strings.TrimPrefix(s.Template, "/")
+		}
+		c.PeriodicNotes[period] = note
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
+)
+
+// testVault is where writeVault creates vaults
+const testVault = "/vault"
+
+// writeVault creates a vault at testVault in fsys with the given files, relative
+// to the vault root
+func writeVault(t *testing.T, fsys afero.Fs, files map[string]string) string {
+	t.Helper()
+	if err := fsys.MkdirAll(filepath.Join(testVault, ".obsidian"), 0755); err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(fsys, filepath.Join(testVault, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return testVault
+}
+
+func loadTestConfig(t *testing.T, fsys afero.Fs, content string) (*Config, error) {
+	t.Helper()
+	configPath := "/config/.markin.yaml"
+	if err := afero.WriteFile(fsys, configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	return LoadConfig(fsys, configPath)
+}
+
+func TestLoadConfigObsidianDailyNotes(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "/Journal/Daily", "format": "YYYY/MM/DD ddd", "template": "Templates/Daily"}`,
+	})
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.DailyNotePath != "Journal/Daily" {
+		t.Errorf("Expected DailyNotePath %q, got %q", "Journal/Daily", cfg.DailyNotePath)
+	}
+	if cfg.Template != "Templates/Daily" {
+		t.Errorf("Expected Template %q, got %q", "Templates/Daily", cfg.Template)
+	}
+
+	day := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+	name, err := markdown.RenderDateTemplate(cfg.DailyNoteName, day)
+	if err != nil {
+		t.Fatalf("Failed to render daily note name %q: %v", cfg.DailyNoteName, err)
+	}
+	if expected := "2026/10/17 Sat.md"; name != expected {
+		t.Errorf("Expected daily note name %q, got %q", expected, name)
+	}
+}
+
+func TestLoadConfigObsidianKeepsExplicitSettings(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Daily", "format": "DD-MM-YYYY", "template": "Templates/Daily"}`,
+	})
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\ndaily_note_path: \"log\"\ntemplate: \"Templates/Log\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.DailyNotePath != "log" {
+		t.Errorf("Expected DailyNotePath %q, got %q", "log", cfg.DailyNotePath)
+	}
+	if cfg.Template != "Templates/Log" {
+		t.Errorf("Expected Template %q, got %q", "Templates/Log", cfg.Template)
+	}
+	if expected := `{{moment "DD-MM-YYYY"}}.md`; cfg.DailyNoteName != expected {
+		t.Errorf("Expected DailyNoteName %q, got %q", expected, cfg.DailyNoteName)
+	}
+}
+
+func TestLoadConfigObsidianDefaults(t *testing.T) {
+	// Obsidian writes an empty object until the settings are changed
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{".obsidian/daily-notes.json": `{}`})
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if expected := `{{moment "YYYY-MM-DD"}}.md`; cfg.DailyNoteName != expected {
+		t.Errorf("Expected DailyNoteName %q, got %q", expected, cfg.DailyNoteName)
+	}
+}
+
+func TestLoadConfigObsidianNoDailyNotesSettings(t *testing.T) {
+	// A vault where the daily notes plugin was never configured
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, nil)
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if expected := `{{moment "YYYY-MM-DD"}}.md`; cfg.DailyNoteName != expected {
+		t.Errorf("Expected DailyNoteName %q, got %q", expected, cfg.DailyNoteName)
+	}
+	if cfg.DailyNotePath != "" || cfg.Template != "" {
+		t.Errorf("Expected daily notes at the vault root without template, got %+v", cfg)
+	}
+}
+
+func TestLoadConfigNotAVault(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	if err := fsys.MkdirAll("/notes", 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	// Outside a vault there are no settings to name the daily note after
+	_, err := loadTestConfig(t, fsys, "project_dir: \"/notes\"\n")
+	if err == nil || !strings.Contains(err.Error(), "daily_note_name") {
+		t.Errorf("Expected an error for the missing daily note name, got %v", err)
+	}
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \"/notes\"\ndaily_note_name: \"{{.Date}}.md\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.DailyNotePath != "" || cfg.Template != "" {
+		t.Errorf("Expected blank daily note settings outside a vault, got %+v", cfg)
+	}
+}
+
+func TestLoadConfigObsidianInvalidSettings(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{".obsidian/daily-notes.json": `{"folder": `})
+
+	if _, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\n"); err == nil {
+		t.Error("Expected error for unreadable daily notes settings")
+	}
+}
+
+func TestReadObsidianSettingsPeriodicNotes(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{
+		".obsidian/daily-notes.json": `{"folder": "Core", "format": "YYYY-MM-DD"}`,
+		".obsidian/plugins/periodic-notes/data.json": `{
+			"daily": {"enabled": true, "folder": "Periodic/Daily", "format": "YYYY-MM-DD", "template": "Templates/Day"},
+			"weekly": {"enabled": true, "folder": "Periodic/Weekly", "format": "gggg-[W]ww"},
+			"monthly": {"enabled": false, "folder": "Periodic/Monthly", "format": "YYYY-MM"}
+		}`,
+	})
+
+	settings, err := ReadObsidianSettings(fsys, vault)
+	if err != nil {
+		t.Fatalf("Failed to read Obsidian settings: %v", err)
+	}
+
+	if settings.Daily == nil || settings.Daily.Folder != "Periodic/Daily" {
+		t.Errorf("Expected the Periodic Notes daily settings to win, got %+v", settings.Daily)
+	}
+	if settings.Weekly == nil || settings.Weekly.Format != "gggg-[W]ww" {
+		t.Errorf("Expected weekly settings, got %+v", settings.Weekly)
+	}
+	if settings.Monthly != nil {
+		t.Errorf("Expected disabled monthly notes to be ignored, got %+v", settings.Monthly)
+	}
+}
+
+func TestReadObsidianSettingsPeriodicDailyDisabled(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{
+		".obsidian/daily-notes.json":                 `{"folder": "Core"}`,
+		".obsidian/plugins/periodic-notes/data.json": `{"daily": {"enabled": false, "folder": "Periodic"}}`,
+	})
+
+	settings, err := ReadObsidianSettings(fsys, vault)
+	if err != nil {
+		t.Fatalf("Failed to read Obsidian settings: %v", err)
+	}
+	if settings.Daily == nil || settings.Daily.Folder != "Core" {
+		t.Errorf("Expected the core daily notes settings, got %+v", settings.Daily)
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding configuration
+// keys, e.g. MARKIN_SECTION
+const EnvPrefix = "MARKIN"
+
+// KeyConfig is the override key of the configuration file to use instead of the
+// user and project configuration
+const KeyConfig = "config"
+
+// OverrideFlags maps the configuration keys with a command line flag to the flag
+var OverrideFlags = map[string]string{
+	"project_dir":               "vault",
+	"section":                   "section",
+	"position":                  "position",
+	"create_section_if_missing": "create-section",
+}
+
+// NewOverrides returns a viper instance reading the MARKIN_* environment variables
+// of the overridable configuration keys. Flags bound to it take precedence over the
+// environment.
+func NewOverrides() *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.BindEnv(KeyConfig)
+	for key := range (&Config{}).overridable() {
+		v.BindEnv(key)
+	}
+	// MARKIN_VAULT reads better than MARKIN_PROJECT_DIR
+	v.BindEnv("project_dir", EnvPrefix+"_VAULT")
+	return v
+}
+
+// overridable returns the fields of the configuration keys that flags and
+// environment variables can override
+func (c *Config) overridable() map[string]any {
+	return map[string]any{
+		"project_dir":               &c.ProjectDir,
+		"daily_note_path":           &c.DailyNotePath,
+		"daily_note_name":           &c.DailyNoteName,
+		"template":                  &c.Template,
+		"section":                   &c.Section,
+		"section_match":             &c.SectionMatch,
+		"position":                  &c.Position,
+		"subheading":                &c.Subheading,
+		"marker":                    &c.Marker,
+		"create_section_if_missing": &c.CreateSectionIfMissing,
+		"sanitize":                  &c.Sanitize,
+		"entry_format":              &c.EntryFormat,
+		"time_format":               &c.TimeFormat,
+		"timezone":                  &c.Timezone,
+	}
+}
+
+// applyOverrides sets the keys set in v by a flag or an environment variable.
+// section and position apply to every entry type and periodic note, since they
+// say where this invocation's entry goes.
+func (c *Config) applyOverrides(v *viper.Viper) {
+	if v == nil {
+		return
+	}
+	for key, field := range c.overridable() {
+		if !v.IsSet(key) {
+			continue
+		}
+		switch f := field.(type) {
+		case *string:
+			*f = v.GetString(key)
+		case *bool:
+			*f = v.GetBool(key)
+		}
+	}
+
+	if v.IsSet("section") {
+		for i := range c.EntryTypes {
+			c.EntryTypes[i].Section = c.Section
+		}
+		for period, note := range c.PeriodicNotes {
+			note.Section = c.Section
+			c.PeriodicNotes[period] = note
+		}
+	}
+	if v.IsSet("position") {
+		for i := range c.EntryTypes {
+			c.EntryTypes[i].Position = c.Position
+		}
+	}
+}
+
+// validateOverrides checks the keys set in v, naming the flag or environment
+// variable that set an invalid value rather than the configuration files
+func (c *Config) validateOverrides(v *viper.Viper) error {
+	if v == nil {
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.overridable())) {
+		if !v.IsSet(key) {
+			continue
+		}
+		if err := c.validateKey(key); err != nil {
+			return fmt.Errorf("invalid %s: %w", overrideSource(v, key), err)
+		}
+	}
+	return nil
+}
+
+// overrideSource names what set key in v: one of its environment variables, or
+// its flag, which wins over them
+func overrideSource(v *viper.Viper, key string) string {
+	envs := []string{EnvPrefix + "_" + strings.ToUpper(key)}
+	if key == "project_dir" {
+		envs = append(envs, EnvPrefix+"_VAULT")
+	}
+	flag := OverrideFlags[key]
+	for _, env := range envs {
+		if value, ok := os.LookupEnv(env); ok && (flag == "" || value == v.GetString(key)) {
+			return "$" + env
+		}
+	}
+	return "--" + flag
+}
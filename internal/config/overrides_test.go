@@ -0,0 +1,20 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadOverridesInvalid(t *testing.T) {
+	t.Setenv("MARKIN_POSITION", "middle")
+	fsys := afero.NewMemMapFs()
+	if err := afero.WriteFile(fsys, "/config/.markin.yaml", []byte(`section: "## Log"`), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	_, err := Load(fsys, LoadOptions{Path: "/config/.markin.yaml", Overrides: NewOverrides()})
+	if err == nil || !strings.Contains(err.Error(), "$MARKIN_POSITION") {
+		t.Errorf("Expected an error naming $MARKIN_POSITION, got %v", err)
+	}
+}
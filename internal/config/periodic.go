@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Periods a note can cover. Daily notes are configured by the top-level settings,
+// the others by periodic_notes.
+const (
+	PeriodDaily     = "daily"
+	PeriodWeekly    = "weekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
+// Periods lists the supported periods
+var Periods = []string{PeriodDaily, PeriodWeekly, PeriodMonthly, PeriodQuarterly, PeriodYearly}
+
+// periodFormats are the default Moment.js name formats of the periodic notes,
+// matching the Periodic Notes plugin
+var periodFormats = map[string]string{
+	PeriodDaily:     defaultObsidianFormat,
+	PeriodWeekly:    "gggg-[W]ww",
+	PeriodMonthly:   "YYYY-MM",
+	PeriodQuarterly: "YYYY-[Q]Q",
+	PeriodYearly:    "YYYY",
+}
+
+// PeriodicNote configures the notes of a period
+type PeriodicNote struct {
+	// Path is the directory of the notes relative to project_dir (can use date templates)
+	Path string `yaml:"path"`
+	// Name is the note file name, rendered as a date template
+	Name string `yaml:"name"`
+	// Template is the Obsidian template new notes are created from
+	Template string `yaml:"template"`
+	// Section is the section entries go to unless their entry type sets one
+	Section string `yaml:"section"`
+}
+
+// Target is the note an entry goes to and the section within it
+type Target struct {
+	Period string
+	// Path and Name are the date templates of the note's directory and file name
+	Path string
+	Name string
+	// Template is the full path of the template new notes are created from
+	Template string
+	Section  string
+	// SectionOrder and ScaffoldSections describe the daily note and are empty for
+	// the other periods
+	SectionOrder     []string
+	ScaffoldSections bool
+}
+
+// Target returns the note entries of type et go to for period. An empty period
+// means the entry type's period, and daily when it has none.
+func (c *Config) Target(et EntryType, period string) (Target, error) {
+	if period == "" {
+		period = et.Period
+	}
+	if period == "" {
+		period = PeriodDaily
+	}
+	if !slices.Contains(Periods, period) {
+		return Target{}, fmt.Errorf("invalid period %q, expected one of: %s", period, strings.Join(Periods, ", "))
+	}
+
+	if period == PeriodDaily {
+		return Target{
+			Period:           period,
+			Path:             c.DailyNotePath,
+			Name:             c.DailyNoteName,
+			Template:         c.TemplatePath(),
+			Section:          c.section(et.Name, period),
+			SectionOrder:     c.SectionOrder,
+			ScaffoldSections: c.ScaffoldSections,
+		}, nil
+	}
+
+	note := c.PeriodicNotes[period]
+	name := note.Name
+	if name == "" {
+		name = momentName(periodFormats[period])
+	}
+	return Target{
+		Period:   period,
+		Path:     note.Path,
+		Name:     name,
+		Template: resolveTemplate(c.ProjectDir, note.Template),
+		Section:  c.section(et.Name, period),
+	}, nil
+}
+
+// section returns the section entries of the named type go to in a note of the
+// period: the type's own section, then the periodic note's, then the top-level one
+func (c *Config) section(name, period string) string {
+	for _, et := range c.EntryTypes {
+		if et.Name == name && et.Section != "" {
+			return et.Section
+		}
+	}
+	if section := c.PeriodicNotes[period].Section; section != "" {
+		return section
+	}
+	return c.Section
+}
+
+// validatePeriodicNotes checks the periodic_notes settings
+func (c *Config) validatePeriodicNotes() error {
+	for period, note := range c.PeriodicNotes {
+		if period == PeriodDaily || !slices.Contains(Periods, period) {
+			return fmt.Errorf("periodic_notes: invalid period %q, expected one of: %s", period, strings.Join(Periods[1:], ", "))
+		}
+		if err := validateDateTemplate("periodic_notes."+period+".path", note.Path); err != nil {
+			return err
+		}
+		if err := validateDateTemplate("periodic_notes."+period+".name", note.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carlisia/markin/pkg/markdown"
+	"github.com/spf13/afero"
+)
+
+func TestTarget(t *testing.T) {
+	cfg, err := loadTestConfig(t, afero.NewMemMapFs(), `project_dir: "/vault"
+daily_note_path: "daily"
+daily_note_name: "{{.Date}}.md"
+template: "Templates/Daily"
+section: "## Log"
+section_order: ["## Log", "## Review"]
+periodic_notes:
+  weekly:
+    path: "weekly"
+    template: "Templates/Weekly"
+    section: "## Goals"
+  monthly:
+    path: "monthly/{{.Year}}"
+    name: "{{moment \"MMMM\"}}.md"
+entry_types:
+  - name: fl
+  - name: goal
+    period: weekly
+  - name: review
+    period: monthly
+    section: "## Review"
+`)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	types := cfg.Types()
+	fl, goal, review := types[0], types[1], types[2]
+
+	day := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		et       EntryType
+		period   string
+		path     string
+		template string
+		section  string
+	}{
+		{"daily by default", fl, "", "/vault/daily/2026-10-17.md", "/vault/Templates/Daily.md", "## Log"},
+		{"entry type period", goal, "", "/vault/weekly/2026-W42.md", "/vault/Templates/Weekly.md", "## Goals"},
+		{"period overrides the entry type", goal, "daily", "/vault/daily/2026-10-17.md", "/vault/Templates/Daily.md", "## Log"},
+		{"period of a daily type", fl, "weekly", "/vault/weekly/2026-W42.md", "/vault/Templates/Weekly.md", "## Goals"},
+		{"entry type section wins", review, "", "/vault/monthly/2026/October.md", "", "## Review"},
+		{"unconfigured period", fl, "quarterly", "/vault/2026-Q4.md", "", "## Log"},
+		{"yearly", fl, "yearly", "/vault/2026.md", "", "## Log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := cfg.Target(tt.et, tt.period)
+			if err != nil {
+				t.Fatalf("Failed to resolve target: %v", err)
+			}
+			path, err := markdown.ResolvePath(cfg.ProjectDir, target.Path, target.Name, day, false)
+			if err != nil {
+				t.Fatalf("Failed to resolve path: %v", err)
+			}
+			if path != filepath.FromSlash(tt.path) {
+				t.Errorf("Expected path %q, got %q", tt.path, path)
+			}
+			if target.Template != filepath.FromSlash(tt.template) {
+				t.Errorf("Expected template %q, got %q", tt.template, target.Template)
+			}
+			if target.Section != tt.section {
+				t.Errorf("Expected section %q, got %q", tt.section, target.Section)
+			}
+			if target.Period != "daily" && target.SectionOrder != nil {
+				t.Errorf("Expected no section order for %s notes, got %v", target.Period, target.SectionOrder)
+			}
+		})
+	}
+
+	if goal.Section != "## Goals" {
+		t.Errorf("Expected goal type to default to the weekly section, got %q", goal.Section)
+	}
+	if goal.Description != "Add a goal entry to your weekly note" {
+		t.Errorf("Unexpected goal description %q", goal.Description)
+	}
+}
+
+func TestTargetInvalidPeriod(t *testing.T) {
+	cfg := &Config{}
+	if _, err := cfg.Target(DefaultEntryTypes()[0], "hourly"); err == nil {
+		t.Error("Expected error for invalid period")
+	}
+}
+
+func TestLoadConfigInvalidPeriods(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown periodic note", "periodic_notes:\n  hourly:\n    path: \"hours\"\n"},
+		{"daily periodic note", "periodic_notes:\n  daily:\n    path: \"daily\"\n"},
+		{"unknown entry type period", "entry_types:\n  - name: goal\n    period: \"fortnightly\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestConfig(t, afero.NewMemMapFs(), tt.content); err == nil {
+				t.Error("Expected error for invalid period")
+			}
+		})
+	}
+}
+
+func TestLoadConfigObsidianPeriodicNotes(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{
+		".obsidian/plugins/periodic-notes/data.json": `{
+			"weekly": {"enabled": true, "folder": "/Periodic/Weekly", "format": "YYYY-[Week]-ww", "template": "Templates/Weekly"},
+			"monthly": {"enabled": true, "folder": "Periodic/Monthly"}
+		}`,
+	})
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\nperiodic_notes:\n  weekly:\n    section: \"## Goals\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	weekly := cfg.PeriodicNotes[PeriodWeekly]
+	if weekly.Path != "Periodic/Weekly" || weekly.Template != "Templates/Weekly" || weekly.Section != "## Goals" {
+		t.Errorf("Unexpected weekly note settings %+v", weekly)
+	}
+	if expected := `{{moment "YYYY-[Week]-ww"}}.md`; weekly.Name != expected {
+		t.Errorf("Expected weekly name %q, got %q", expected, weekly.Name)
+	}
+	if expected := `{{moment "YYYY-MM"}}.md`; cfg.PeriodicNotes[PeriodMonthly].Name != expected {
+		t.Errorf("Expected monthly name %q, got %q", expected, cfg.PeriodicNotes[PeriodMonthly].Name)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Storage backends notes can live on
+const (
+	// StorageLocal reads and writes notes on the local disk
+	StorageLocal = "local"
+	// StorageWebDAV reads and writes notes on a WebDAV share such as Nextcloud
+	StorageWebDAV = "webdav"
+)
+
+// StorageBackends lists the supported storage backends
+var StorageBackends = []string{StorageLocal, StorageWebDAV}
+
+// defaultPasswordEnv is the environment variable the WebDAV password is read from
+// when password_env is not set
+const defaultPasswordEnv = "MARKIN_WEBDAV_PASSWORD"
+
+// Storage is where notes are read from and written to
+type Storage struct {
+	// Backend is StorageLocal (the default) or StorageWebDAV
+	Backend string `yaml:"backend"`
+	// URL is the address of the WebDAV share the vault lives in. project_dir and
+	// note paths are relative to it.
+	URL string `yaml:"url"`
+	// Username is the WebDAV user, environment variables are expanded
+	Username string `yaml:"username"`
+	// PasswordEnv names the environment variable holding the WebDAV password, so it
+	// never has to be written in the configuration file
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// IsWebDAV reports whether notes live on a WebDAV share
+func (s Storage) IsWebDAV() bool {
+	return s.Backend == StorageWebDAV
+}
+
+// Credentials returns the WebDAV username and password from the environment
+func (s Storage) Credentials() (username, password string) {
+	env := s.PasswordEnv
+	if env == "" {
+		env = defaultPasswordEnv
+	}
+	return os.ExpandEnv(s.Username), os.Getenv(env)
+}
+
+// validate checks the storage settings
+func (s Storage) validate() error {
+	switch s.Backend {
+	case "", StorageLocal:
+		return nil
+	case StorageWebDAV:
+	default:
+		return fmt.Errorf("storage.backend: invalid value %q, expected one of: %s", s.Backend, strings.Join(StorageBackends, ", "))
+	}
+
+	if s.URL == "" {
+		return fmt.Errorf("storage.url: required for the %s backend", s.Backend)
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("storage.url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("storage.url: %q must be an http or https URL", s.URL)
+	}
+	return nil
+}
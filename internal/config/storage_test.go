@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestLoadConfigStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"default", `project_dir: "/vault"`, true},
+		{"local", "storage:\n  backend: local\n", true},
+		{"webdav", "storage:\n  backend: webdav\n  url: \"https://cloud.example.com/remote.php/dav/files/me\"\n", true},
+		{"unknown backend", "storage:\n  backend: ftp\n", false},
+		{"webdav without url", "storage:\n  backend: webdav\n", false},
+		{"webdav url without scheme", "storage:\n  backend: webdav\n  url: \"cloud.example.com/dav\"\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestConfig(t, afero.NewMemMapFs(), "daily_note_name: \"{{.Date}}.md\"\n"+tt.content)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid config, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected error for invalid storage")
+			}
+		})
+	}
+}
+
+func TestLoadConfigWebDAVSkipsObsidianSettings(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := writeVault(t, fsys, map[string]string{".obsidian/daily-notes.json": `{"folder": "Daily"}`})
+
+	cfg, err := loadTestConfig(t, fsys, "project_dir: \""+vault+"\"\ndaily_note_name: \"{{.Date}}.md\"\nstorage:\n  backend: webdav\n  url: \"https://cloud.example.com/dav\"\n")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.DailyNotePath != "" {
+		t.Errorf("Expected local Obsidian settings to be ignored, got DailyNotePath %q", cfg.DailyNotePath)
+	}
+}
+
+func TestStorageCredentials(t *testing.T) {
+	t.Setenv("NEXTCLOUD_USER", "me")
+	t.Setenv("NEXTCLOUD_PASSWORD", "secret")
+	t.Setenv("MARKIN_WEBDAV_PASSWORD", "default")
+
+	username, password := Storage{Username: "$NEXTCLOUD_USER", PasswordEnv: "NEXTCLOUD_PASSWORD"}.Credentials()
+	if username != "me" || password != "secret" {
+		t.Errorf("Expected me/secret, got %s/%s", username, password)
+	}
+	if _, password := (Storage{Username: "me"}).Credentials(); password != "default" {
+		t.Errorf("Expected the password from MARKIN_WEBDAV_PASSWORD, got %q", password)
+	}
+}
@@ -0,0 +1,215 @@
+// Package dateparse parses the natural-language dates and clock times accepted by
+// --date and --at, such as "yesterday", "last friday", "2026-10-01" or "2:30pm"
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// relativeRe matches "3 days ago", "2 weeks ago" and "in 4 days"
+	relativeRe = regexp.MustCompile(`^(?:(\d+)\s+(day|week|month|year)s?\s+ago|in\s+(\d+)\s+(day|week|month|year)s?)$`)
+	// offsetRe matches "+2", "-1" and "-3d" day offsets
+	offsetRe = regexp.MustCompile(`^([+-]\d+)d?$`)
+	// weekdayRe matches "friday", "last fri", "next monday" and "this sunday"
+	weekdayRe = regexp.MustCompile(`^(?:(last|next|this)\s+)?([a-z]+)$`)
+	// monthDayRe matches "oct 1", "october 1st" and "oct 1, 2026"
+	monthDayRe = regexp.MustCompile(`^([a-z]+)\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?$`)
+	// dayMonthRe matches "1 oct" and "1st october 2026"
+	dayMonthRe = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?\s+([a-z]+)\.?(?:,?\s+(\d{4}))?$`)
+	// clockRe matches "14:30", "9:05:10", "2:30pm", "2pm" and "9 am"
+	clockRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?\s*([ap]\.?m\.?)?$`)
+)
+
+// dateLayouts are the absolute date formats Date accepts
+var dateLayouts = []string{"2006-01-02", "2006/01/02", "20060102"}
+
+// Date parses a date relative to now and returns that day at now's time of day.
+// It accepts today, yesterday, tomorrow, ISO dates (2026-10-01), weekdays with an
+// optional last/next/this, month names (oct 1, 1 october 2026), "3 days ago",
+// "in 2 weeks" and day offsets such as -1. A bare weekday means its most recent
+// occurrence, today included.
+func Date(s string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.Join(strings.Fields(s), " "))
+
+	switch text {
+	case "":
+		return time.Time{}, fmt.Errorf("date is empty")
+	case "today", "now":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if d, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return onDay(now, d.Year(), d.Month(), d.Day()), nil
+		}
+	}
+
+	if m := offsetRe.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return now.AddDate(0, 0, n), nil
+	}
+
+	if m := relativeRe.FindStringSubmatch(text); m != nil {
+		n, unit := m[1], m[2]
+		sign := -1
+		if n == "" {
+			n, unit, sign = m[3], m[4], 1
+		}
+		amount, _ := strconv.Atoi(n)
+		return shift(now, sign*amount, unit), nil
+	}
+
+	if m := weekdayRe.FindStringSubmatch(text); m != nil {
+		if day, ok := weekday(m[2]); ok {
+			return relativeWeekday(now, m[1], day), nil
+		}
+	}
+
+	if m := monthDayRe.FindStringSubmatch(text); m != nil {
+		if d, ok := monthDay(now, m[1], m[2], m[3]); ok {
+			return d, nil
+		}
+	}
+	if m := dayMonthRe.FindStringSubmatch(text); m != nil {
+		if d, ok := monthDay(now, m[2], m[1], m[3]); ok {
+			return d, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// At returns t with its time of day set to the clock time s, e.g. 14:30, 9:05:10,
+// 2:30pm, 2pm, noon or midnight
+func At(s string, t time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+
+	var hour, minute, second int
+	switch text {
+	case "noon":
+		hour = 12
+	case "midnight":
+	default:
+		m := clockRe.FindStringSubmatch(text)
+		if m == nil {
+			return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+		}
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		second, _ = strconv.Atoi(m[3])
+
+		if meridiem := m[4]; meridiem != "" {
+			if hour < 1 || hour > 12 {
+				return time.Time{}, fmt.Errorf("invalid time %q", s)
+			}
+			hour %= 12
+			if meridiem[0] == 'p' {
+				hour += 12
+			}
+		} else if m[2] == "" {
+			// A bare number is ambiguous, e.g. a year or a duration
+			return time.Time{}, fmt.Errorf("unrecognized time %q, expected e.g. 14:30 or 2pm", s)
+		}
+		if hour > 23 || minute > 59 || second > 59 {
+			return time.Time{}, fmt.Errorf("invalid time %q", s)
+		}
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, second, 0, t.Location()), nil
+}
+
+// onDay returns the given day at now's time of day
+func onDay(now time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), now.Location())
+}
+
+// shift moves t by n days, weeks, months or years
+func shift(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// relativeWeekday returns the weekday day relative to now: the most recent one
+// (today included) without a modifier, the one before today for "last", the one
+// after today for "next" and the one of the current Monday-based week for "this"
+func relativeWeekday(now time.Time, modifier string, day time.Weekday) time.Time {
+	diff := int(day) - int(now.Weekday())
+	switch modifier {
+	case "last":
+		if diff >= 0 {
+			diff -= 7
+		}
+	case "next":
+		if diff <= 0 {
+			diff += 7
+		}
+	case "this":
+		// Sunday ends the week
+		diff = (int(day)+6)%7 - (int(now.Weekday())+6)%7
+	default:
+		if diff > 0 {
+			diff -= 7
+		}
+	}
+	return now.AddDate(0, 0, diff)
+}
+
+// weekday parses a weekday name or its abbreviation
+func weekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// monthDay returns the date of a month name, a day and an optional year, in the
+// year of now when none is given
+func monthDay(now time.Time, monthName, dayText, yearText string) (time.Time, bool) {
+	month, ok := parseMonth(monthName)
+	if !ok {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(dayText)
+	year := now.Year()
+	if yearText != "" {
+		year, _ = strconv.Atoi(yearText)
+	}
+
+	d := onDay(now, year, month, day)
+	if d.Day() != day {
+		// e.g. February 30
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// parseMonth parses a month name or its abbreviation
+func parseMonth(name string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		full := strings.ToLower(m.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return m, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+// now is the fixed clock the tests parse against: Saturday, October 17, 2026
+var now = time.Date(2026, time.October, 17, 10, 15, 30, 0, time.UTC)
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"today", "2026-10-17"},
+		{"Now", "2026-10-17"},
+		{"yesterday", "2026-10-16"},
+		{"tomorrow", "2026-10-18"},
+		{"2026-10-01", "2026-10-01"},
+		{"2025/12/31", "2025-12-31"},
+		{"20261001", "2026-10-01"},
+		{"-1", "2026-10-16"},
+		{"+2d", "2026-10-19"},
+		{"3 days ago", "2026-10-14"},
+		{"1 day ago", "2026-10-16"},
+		{"2 weeks ago", "2026-10-03"},
+		{"1 month ago", "2026-09-17"},
+		{"in 2 days", "2026-10-19"},
+		{"in 1 week", "2026-10-24"},
+		{"friday", "2026-10-16"},
+		{"saturday", "2026-10-17"},
+		{"sun", "2026-10-11"},
+		{"last friday", "2026-10-16"},
+		{"last saturday", "2026-10-10"},
+		{"Last  Friday", "2026-10-16"},
+		{"next friday", "2026-10-23"},
+		{"next saturday", "2026-10-24"},
+		{"next mon", "2026-10-19"},
+		{"this monday", "2026-10-12"},
+		{"this sunday", "2026-10-18"},
+		{"oct 1", "2026-10-01"},
+		{"October 1st", "2026-10-01"},
+		{"sept 30", "2026-09-30"},
+		{"dec 24, 2025", "2025-12-24"},
+		{"1 oct", "2026-10-01"},
+		{"3rd march 2027", "2027-03-03"},
+	}
+
+	for _, tt := range tests {
+		got, err := Date(tt.input, now)
+		if err != nil {
+			t.Errorf("Date(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if d := got.Format("2006-01-02"); d != tt.expected {
+			t.Errorf("Date(%q) = %s, expected %s", tt.input, d, tt.expected)
+		}
+		if got.Hour() != now.Hour() || got.Minute() != now.Minute() || got.Second() != now.Second() {
+			t.Errorf("Date(%q) = %s, expected the time of day to be kept", tt.input, got)
+		}
+	}
+}
+
+func TestDateInvalid(t *testing.T) {
+	for _, input := range []string{"", "someday", "last week", "feb 30", "2026-13-01", "in days", "oct"} {
+		if got, err := Date(input, now); err == nil {
+			t.Errorf("Date(%q) = %s, expected an error", input, got)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"14:30", "14:30:00"},
+		{"9:05", "09:05:00"},
+		{"09:05:10", "09:05:10"},
+		{"2:30pm", "14:30:00"},
+		{"2:30 PM", "14:30:00"},
+		{"2pm", "14:00:00"},
+		{"9 am", "09:00:00"},
+		{"12am", "00:00:00"},
+		{"12:15 p.m.", "12:15:00"},
+		{"noon", "12:00:00"},
+		{"midnight", "00:00:00"},
+	}
+
+	for _, tt := range tests {
+		got, err := At(tt.input, now)
+		if err != nil {
+			t.Errorf("At(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if clock := got.Format("15:04:05"); clock != tt.expected {
+			t.Errorf("At(%q) = %s, expected %s", tt.input, clock, tt.expected)
+		}
+		if got.Format("2006-01-02") != "2026-10-17" || got.Location() != now.Location() {
+			t.Errorf("At(%q) = %s, expected the day and location to be kept", tt.input, got)
+		}
+	}
+}
+
+func TestAtInvalid(t *testing.T) {
+	for _, input := range []string{"", "14", "25:00", "10:60", "13pm", "0am", "teatime"} {
+		if got, err := At(input, now); err == nil {
+			t.Errorf("At(%q) = %s, expected an error", input, got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package entry
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultFormat renders entries like "- ⚡ *06:33:45 pm:* **Fleeting**:: note"
+const DefaultFormat = `- {{with .Emoji}}{{.}} {{end}}*{{.Timestamp}}:* {{with .Label}}**{{.}}**:: {{end}}{{.Text}}`
+
+// DefaultTimeFormat is the timestamp layout used when none is configured
+const DefaultTimeFormat = "03:04:05 pm"
+
+// timePresets maps named time formats to Go layouts
+var timePresets = map[string]string{
+	"12h":         "03:04 pm",
+	"12h-seconds": "03:04:05 pm",
+	"24h":         "15:04",
+	"24h-seconds": "15:04:05",
+	"iso8601":     "2006-01-02T15:04:05Z07:00",
+	"iso8601-utc": "2006-01-02T15:04:05Z",
+}
+
+// utcPresets are the named time formats that render times in UTC rather than in
+// the configured time zone, since their layout marks them as UTC
+var utcPresets = map[string]bool{
+	"iso8601-utc": true,
+}
+
+// TimeLayout returns the Go layout for a named preset (12h, 24h, 24h-seconds, iso8601, ...)
+// or the format itself when it is already a Go layout
+func TimeLayout(format string) string {
+	if format == "" {
+		return DefaultTimeFormat
+	}
+	if layout, ok := timePresets[strings.ToLower(format)]; ok {
+		return layout
+	}
+	return format
+}
+
+// formatTime renders t with a Go layout or a named preset, converting it to UTC
+// for the UTC presets
+func formatTime(t time.Time, format string) string {
+	if utcPresets[strings.ToLower(format)] {
+		t = t.UTC()
+	}
+	return t.Format(TimeLayout(format))
+}
+
+// Data is the data available to entry format templates
+type Data struct {
+	// Text is the note text as given by the user
+	Text string
+	// Time is the capture time
+	Time time.Time
+	// Timestamp is Time rendered with the configured time format
+	Timestamp string
+	// Type is the entry type name, e.g. "fl"
+	Type   string
+	Label  string
+	Emoji  string
+	Tags   []string
+	Dir    string
+	Host   string
+	Fields map[string]string
+}
+
+var funcs = template.FuncMap{
+	// hashtags renders tags as Obsidian tags, e.g. "#work #idea"
+	"hashtags": func(tags []string) string {
+		out := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			out = append(out, "#"+strings.TrimPrefix(tag, "#"))
+		}
+		return strings.Join(out, " ")
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	// date formats a time with a Go layout or a named preset
+	"date": func(format string, t time.Time) string { return formatTime(t, format) },
+}
+
+// Formatter renders entries with a parsed entry format template
+type Formatter struct {
+	tmpl       *template.Template
+	timeFormat string
+	location   *time.Location
+}
+
+// NewFormatter parses an entry format and returns a Formatter. An empty format uses
+// DefaultFormat, an empty time format DefaultTimeFormat and a nil location the local time zone.
+func NewFormatter(format, timeFormat string, loc *time.Location) (*Formatter, error) {
+	if format == "" {
+		format = DefaultFormat
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	// Fields not given on the command line render empty rather than as "<no value>"
+	tmpl, err := template.New("entry").Funcs(funcs).Option("missingkey=zero").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry format: %w", err)
+	}
+
+	return &Formatter{
+		tmpl:       tmpl,
+		timeFormat: timeFormat,
+		location:   loc,
+	}, nil
+}
+
+// Format renders an entry. Timestamp is derived from Time when left empty.
+// Only the first line of a multi-line Text goes through the format; the other
+// lines are nested under the entry so they don't break the surrounding list.
+func (f *Formatter) Format(data Data) (string, error) {
+	data.Time = data.Time.In(f.location)
+	if data.Timestamp == "" {
+		data.Timestamp = formatTime(data.Time, f.timeFormat)
+	}
+
+	text := strings.Trim(strings.ReplaceAll(data.Text, "\r\n", "\n"), "\n")
+	first, rest, multiline := strings.Cut(text, "\n")
+	data.Text = first
+
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render entry format: %w", err)
+	}
+	if !multiline {
+		return buf.String(), nil
+	}
+	return buf.String() + "\n" + nest(rest, buf.String()), nil
+}
+
+var (
+	// listMarkerRe matches the marker of a list item, e.g. "- ", "* " or "1. "
+	listMarkerRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+	// quoteMarkerRe matches the markers of a blockquote or callout, e.g. "> "
+	quoteMarkerRe = regexp.MustCompile(`^\s*(?:>\s?)+`)
+)
+
+// nest indents the continuation lines of a multi-line entry to nest them under the
+// entry's first line: as the content of a list item, or inside a blockquote or callout
+func nest(lines, entry string) string {
+	first, _, _ := strings.Cut(entry, "\n")
+
+	var prefix, blank string
+	if m := listMarkerRe.FindString(first); m != "" {
+		prefix = strings.Repeat(" ", len(m))
+	} else if m := quoteMarkerRe.FindString(first); m != "" {
+		prefix, blank = m, strings.TrimRight(m, " ")
+	}
+
+	nested := strings.Split(lines, "\n")
+	for i, line := range nested {
+		if strings.TrimSpace(line) == "" {
+			nested[i] = blank
+			continue
+		}
+		nested[i] = prefix + line
+	}
+	return strings.Join(nested, "\n")
+}
+
+// Validate checks that an entry format parses and renders against sample data,
+// so typos in field names are caught before anything is written
+func Validate(format string) error {
+	f, err := NewFormatter(format, "", time.UTC)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Format(Data{
+		Text:   "sample",
+		Time:   time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC),
+		Type:   "sample",
+		Label:  "Sample",
+		Emoji:  "⚡",
+		Tags:   []string{"sample"},
+		Dir:    "/tmp",
+		Host:   "localhost",
+		Fields: map[string]string{},
+	})
+	return err
+}
@@ -0,0 +1,216 @@
+package entry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDefault(t *testing.T) {
+	f, err := NewFormatter("", "", time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+
+	got, err := f.Format(Data{
+		Text:  "Your fleeting thought here",
+		Time:  time.Date(2026, time.October, 17, 18, 33, 45, 0, time.UTC),
+		Label: "Fleeting",
+		Emoji: "⚡",
+	})
+	if err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+
+	expected := "- ⚡ *06:33:45 pm:* **Fleeting**:: Your fleeting thought here"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatWithoutEmojiAndLabel(t *testing.T) {
+	f, err := NewFormatter("", "24h", time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+
+	got, err := f.Format(Data{
+		Text: "plain",
+		Time: time.Date(2026, time.October, 17, 18, 33, 45, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+
+	if expected := "- *18:33:* plain"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+
+	format := `- {{.Timestamp}} [{{.Type}}] {{.Text}} {{hashtags .Tags}} ({{.Fields.project}} @ {{.Host}})`
+	f, err := NewFormatter(format, "iso8601", berlin)
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+
+	got, err := f.Format(Data{
+		Text:   "Ship it",
+		Time:   time.Date(2026, time.October, 17, 8, 0, 0, 0, time.UTC),
+		Type:   "log",
+		Tags:   []string{"work", "#release"},
+		Host:   "laptop",
+		Fields: map[string]string{"project": "markin"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+
+	expected := "- 2026-10-17T10:00:00+02:00 [log] Ship it #work #release (markin @ laptop)"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatMissingField(t *testing.T) {
+	f, err := NewFormatter(`- {{.Text}}{{with .Fields.author}} — {{.}}{{end}} ({{.Fields.source}})`, "", time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+
+	got, err := f.Format(Data{Text: "Quote", Time: time.Date(2026, time.October, 17, 8, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+	expected := "- Quote ()"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatMultiline(t *testing.T) {
+	entryTime := time.Date(2026, time.October, 17, 18, 33, 45, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   string
+		text     string
+		expected string
+	}{
+		{
+			name:     "continuation nested under the list item",
+			format:   "- {{.Text}}",
+			text:     "Meeting notes\nagreed on the plan\n- follow up\n\nnext steps",
+			expected: "- Meeting notes\n  agreed on the plan\n  - follow up\n\n  next steps",
+		},
+		{
+			name:     "task item",
+			format:   "- [ ] {{.Text}} #todo",
+			text:     "Call the bank\r\nabout the card\r\n",
+			expected: "- [ ] Call the bank #todo\n  about the card",
+		},
+		{
+			name:     "ordered item",
+			format:   "1. {{.Text}}",
+			text:     "first\nsecond",
+			expected: "1. first\n   second",
+		},
+		{
+			name:     "callout",
+			format:   "> [!note] {{.Timestamp}}\n> {{.Text}}",
+			text:     "first\n\nsecond",
+			expected: "> [!note] 06:33:45 pm\n> first\n>\n> second",
+		},
+		{
+			name:     "paragraph",
+			format:   "{{.Timestamp}} {{.Text}}",
+			text:     "first\nsecond",
+			expected: "06:33:45 pm first\nsecond",
+		},
+		{
+			name:     "surrounding blank lines",
+			format:   "- {{.Text}}",
+			text:     "\nsingle\n\n",
+			expected: "- single",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFormatter(tt.format, "", time.UTC)
+			if err != nil {
+				t.Fatalf("Failed to create formatter: %v", err)
+			}
+			got, err := f.Format(Data{Text: tt.text, Time: entryTime})
+			if err != nil {
+				t.Fatalf("Failed to format entry: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatUTCPreset(t *testing.T) {
+	pacific := time.FixedZone("PDT", -7*60*60)
+	f, err := NewFormatter("- {{.Timestamp}} {{date \"iso8601-utc\" .Time}} {{.Text}}", "iso8601-utc", pacific)
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+
+	got, err := f.Format(Data{Text: "note", Time: time.Date(2026, time.October, 17, 20, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+	expected := "- 2026-10-17T20:00:00Z 2026-10-17T20:00:00Z note"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	tests := map[string]string{
+		"":            DefaultTimeFormat,
+		"12h":         "03:04 pm",
+		"24h":         "15:04",
+		"24H-Seconds": "15:04:05",
+		"iso8601":     "2006-01-02T15:04:05Z07:00",
+		"15h04":       "15h04",
+	}
+
+	for format, expected := range tests {
+		if got := TimeLayout(format); got != expected {
+			t.Errorf("TimeLayout(%q) = %q, expected %q", format, got, expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"",
+		DefaultFormat,
+		"- {{.Text}}",
+		"- {{date \"24h\" .Time}} {{upper .Label}} {{join .Tags \", \"}} {{.Fields.missing}}",
+	}
+	for _, format := range valid {
+		if err := Validate(format); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", format, err)
+		}
+	}
+
+	invalid := []string{
+		"- {{.Text",
+		"- {{.Txt}}",
+		"- {{shout .Text}}",
+	}
+	for _, format := range invalid {
+		if err := Validate(format); err == nil {
+			t.Errorf("Expected %q to be invalid", format)
+		}
+	}
+}
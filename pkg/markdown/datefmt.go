@@ -0,0 +1,278 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// momentTokens lists the Moment.js formatting tokens understood by FormatMoment,
+// longest first so that "YYYY" wins over "YY" and "MMMM" over "MM".
+var momentTokens = []string{
+	"GGGG", "gggg", "YYYY", "MMMM", "dddd", "DDDD",
+	"MMM", "ddd", "DDD", "SSS",
+	"YY", "GG", "gg", "MM", "DD", "Do", "dd", "WW", "ww", "HH", "hh", "mm", "ss", "ZZ",
+	"Q", "M", "D", "d", "E", "e", "W", "w", "H", "h", "k", "m", "s", "A", "a", "Z", "X", "x",
+}
+
+// FormatMoment formats t using Moment.js style tokens (YYYY-MM-DD, dddd, [W]WW, ...),
+// the syntax Obsidian uses for daily note names. Text inside square brackets is
+// copied verbatim.
+func FormatMoment(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); {
+		if layout[i] == '[' {
+			end := strings.IndexByte(layout[i:], ']')
+			if end > 0 {
+				b.WriteString(layout[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, tok := range momentTokens {
+			if strings.HasPrefix(layout[i:], tok) {
+				b.WriteString(momentToken(t, tok))
+				i += len(tok)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(layout[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// momentToken renders a single Moment.js token
+func momentToken(t time.Time, tok string) string {
+	isoYear, isoWeek := t.ISOWeek()
+	switch tok {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year())
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case "GGGG":
+		return fmt.Sprintf("%04d", isoYear)
+	case "GG":
+		return fmt.Sprintf("%02d", isoYear%100)
+	case "gggg":
+		return fmt.Sprintf("%04d", localeWeekYear(t))
+	case "gg":
+		return fmt.Sprintf("%02d", localeWeekYear(t)%100)
+	case "Q":
+		return strconv.Itoa(Quarter(t))
+	case "MMMM":
+		return t.Month().String()
+	case "MMM":
+		return t.Month().String()[:3]
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case "M":
+		return strconv.Itoa(int(t.Month()))
+	case "DDDD":
+		return fmt.Sprintf("%03d", t.YearDay())
+	case "DDD":
+		return strconv.Itoa(t.YearDay())
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day())
+	case "D":
+		return strconv.Itoa(t.Day())
+	case "Do":
+		return ordinal(t.Day())
+	case "dddd":
+		return t.Weekday().String()
+	case "ddd":
+		return t.Weekday().String()[:3]
+	case "dd":
+		return t.Weekday().String()[:2]
+	case "d", "e":
+		return strconv.Itoa(int(t.Weekday()))
+	case "E":
+		return strconv.Itoa(isoWeekday(t))
+	case "WW":
+		return fmt.Sprintf("%02d", isoWeek)
+	case "W":
+		return strconv.Itoa(isoWeek)
+	case "ww":
+		return fmt.Sprintf("%02d", localeWeek(t))
+	case "w":
+		return strconv.Itoa(localeWeek(t))
+	case "HH":
+		return fmt.Sprintf("%02d", t.Hour())
+	case "H":
+		return strconv.Itoa(t.Hour())
+	case "hh":
+		return fmt.Sprintf("%02d", hour12(t))
+	case "h":
+		return strconv.Itoa(hour12(t))
+	case "k":
+		// 1-24, midnight being 24
+		if t.Hour() == 0 {
+			return "24"
+		}
+		return strconv.Itoa(t.Hour())
+	case "mm":
+		return fmt.Sprintf("%02d", t.Minute())
+	case "m":
+		return strconv.Itoa(t.Minute())
+	case "ss":
+		return fmt.Sprintf("%02d", t.Second())
+	case "s":
+		return strconv.Itoa(t.Second())
+	case "SSS":
+		return fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+	case "A":
+		return t.Format("PM")
+	case "a":
+		return t.Format("pm")
+	case "Z":
+		return t.Format("-07:00")
+	case "ZZ":
+		return t.Format("-0700")
+	case "X":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "x":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return tok
+}
+
+// Quarter returns the quarter of the year (1-4) t falls in
+func Quarter(t time.Time) int {
+	return (int(t.Month())-1)/3 + 1
+}
+
+// isoWeekday returns the ISO day of the week, Monday being 1 and Sunday 7
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// localeWeek returns the week of the year using Moment's default (en) locale rules:
+// weeks start on Sunday and the week containing January 1st is week 1.
+func localeWeek(t time.Time) int {
+	start := localeWeekStart(t)
+	jan1 := time.Date(localeWeekYear(t), time.January, 1, 0, 0, 0, 0, t.Location())
+	first := jan1.AddDate(0, 0, -int(jan1.Weekday()))
+	return daysBetween(first, start)/7 + 1
+}
+
+// daysBetween returns the number of calendar days from a to b. Days are counted on
+// the dates alone, since a day across a DST change is not 24 hours long.
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// localeWeekYear returns the year the locale week of t belongs to
+func localeWeekYear(t time.Time) int {
+	// The week belongs to the year its Saturday falls in.
+	return localeWeekStart(t).AddDate(0, 0, 6).Year()
+}
+
+// localeWeekStart returns midnight of the Sunday starting the week of t
+func localeWeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -int(t.Weekday()))
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		return 12
+	}
+	return h
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// DateData is the data available to path templates such as daily_note_name
+type DateData struct {
+	Now       time.Time
+	Date      string // 2006-01-02
+	Time      string // 15:04
+	Year      string // 2006
+	Month     string // 01
+	MonthName string // January
+	Day       string // 02
+	Weekday   string // Monday
+	Week      string // ISO week number, 01-53
+	Quarter   int
+}
+
+func newDateData(t time.Time) DateData {
+	_, week := t.ISOWeek()
+	return DateData{
+		Now:       t,
+		Date:      t.Format("2006-01-02"),
+		Time:      t.Format("15:04"),
+		Year:      t.Format("2006"),
+		Month:     t.Format("01"),
+		MonthName: t.Month().String(),
+		Day:       t.Format("02"),
+		Weekday:   t.Weekday().String(),
+		Week:      fmt.Sprintf("%02d", week),
+		Quarter:   Quarter(t),
+	}
+}
+
+// dateFuncs returns the template helpers bound to the capture time t
+func dateFuncs(t time.Time) template.FuncMap {
+	return template.FuncMap{
+		// format renders the capture time with a Go layout, e.g. {{format "2006-01-02"}}
+		"format": func(layout string) string { return t.Format(layout) },
+		// moment renders the capture time with Moment.js tokens, e.g. {{moment "YYYY-MM-DD"}}
+		"moment": func(layout string) string { return FormatMoment(t, layout) },
+		// week returns the zero-padded ISO week number
+		"week": func() string {
+			_, w := t.ISOWeek()
+			return fmt.Sprintf("%02d", w)
+		},
+		// weekday returns the weekday name, e.g. Monday
+		"weekday": func() string { return t.Weekday().String() },
+		// addDays shifts the capture time, e.g. {{(addDays -1).Format "2006-01-02"}}
+		"addDays": func(days int) time.Time { return t.AddDate(0, 0, days) },
+	}
+}
+
+// RenderDateTemplate renders a path template such as "{{.Year}}/{{.Month}}/{{.Date}}.md"
+// or "{{moment \"YYYY/MM/YYYY-MM-DD\"}}.md" against the capture time t.
+// Strings without template actions are returned unchanged.
+func RenderDateTemplate(text string, t time.Time) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("path").Funcs(dateFuncs(t)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid date template %q: %w", text, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newDateData(t)); err != nil {
+		return "", fmt.Errorf("failed to render date template %q: %w", text, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFormatMoment(t *testing.T) {
+	// Thursday, 1 January 2026 is in ISO week 1 of 2026
+	ts := time.Date(2026, time.January, 1, 14, 5, 9, 0, time.UTC)
+
+	tests := []struct {
+		layout   string
+		expected string
+	}{
+		{"YYYY-MM-DD", "2026-01-01"},
+		{"YYYY/MM/YYYY-MM-DD", "2026/01/2026-01-01"},
+		{"dddd, MMMM Do YYYY", "Thursday, January 1st 2026"},
+		{"ddd D MMM YY", "Thu 1 Jan 26"},
+		{"GGGG-[W]WW", "2026-W01"},
+		{"YYYY-[Q]Q", "2026-Q1"},
+		{"HH:mm:ss", "14:05:09"},
+		{"hh:mm A", "02:05 PM"},
+		{"[Daily] YYYY", "Daily 2026"},
+		{"DDDD", "001"},
+		{"E e", "4 4"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoment(ts, tt.layout); got != tt.expected {
+			t.Errorf("FormatMoment(%q) = %q, expected %q", tt.layout, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatMomentWeekYearBoundary(t *testing.T) {
+	// Sunday, 3 January 2027 still belongs to ISO week 53 of 2026
+	ts := time.Date(2027, time.January, 3, 9, 0, 0, 0, time.UTC)
+
+	if got := FormatMoment(ts, "GGGG-[W]WW"); got != "2026-W53" {
+		t.Errorf("Expected ISO week 2026-W53, got %s", got)
+	}
+	if got := FormatMoment(ts, "gggg-[w]ww"); got != "2027-w02" {
+		t.Errorf("Expected locale week 2027-w02, got %s", got)
+	}
+}
+
+func TestFormatMomentDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+
+	// DST starts on Sunday, 8 March 2026 in New York
+	tests := []struct {
+		day      time.Time
+		expected string
+	}{
+		{time.Date(2026, time.March, 7, 12, 0, 0, 0, loc), "2026-W10"},
+		{time.Date(2026, time.March, 8, 12, 0, 0, 0, loc), "2026-W11"},
+		{time.Date(2026, time.March, 15, 12, 0, 0, 0, loc), "2026-W12"},
+		{time.Date(2026, time.June, 15, 0, 30, 0, 0, loc), "2026-W25"},
+		{time.Date(2026, time.November, 8, 12, 0, 0, 0, loc), "2026-W46"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoment(tt.day, "gggg-[W]ww"); got != tt.expected {
+			t.Errorf("FormatMoment(%s) = %q, expected %q", tt.day, got, tt.expected)
+		}
+		utc := time.Date(tt.day.Year(), tt.day.Month(), tt.day.Day(), 12, 0, 0, 0, time.UTC)
+		if got := FormatMoment(utc, "gggg-[W]ww"); got != tt.expected {
+			t.Errorf("FormatMoment(%s) = %q, expected %q as in %s", utc, got, tt.expected, loc)
+		}
+	}
+}
+
+func TestFormatMomentHour24(t *testing.T) {
+	tests := []struct {
+		hour     int
+		expected string
+	}{
+		{0, "24"},
+		{1, "1"},
+		{13, "13"},
+		{23, "23"},
+	}
+
+	for _, tt := range tests {
+		ts := time.Date(2026, time.January, 1, tt.hour, 0, 0, 0, time.UTC)
+		if got := FormatMoment(ts, "k"); got != tt.expected {
+			t.Errorf("FormatMoment(%02d:00, \"k\") = %q, expected %q", tt.hour, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderDateTemplate(t *testing.T) {
+	ts := time.Date(2026, time.October, 17, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"daily.md", "daily.md"},
+		{"{{.Date}}.md", "2026-10-17.md"},
+		{"{{.Year}}/{{.Month}}/{{.Date}}.md", "2026/10/2026-10-17.md"},
+		{`{{moment "YYYY/MM-MMMM/YYYY-MM-DD dddd"}}.md`, "2026/10-October/2026-10-17 Saturday.md"},
+		{`{{format "2006-01-02"}}.md`, "2026-10-17.md"},
+		{"{{.Year}}-W{{week}}.md", "2026-W42.md"},
+		{"{{weekday}}.md", "Saturday.md"},
+		{"{{.Year}}-Q{{.Quarter}}.md", "2026-Q4.md"},
+		{`{{(addDays -1).Format "2006-01-02"}}.md`, "2026-10-16.md"},
+		{"$VAULT/{{.Date}}.md", "$VAULT/2026-10-17.md"},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderDateTemplate(tt.text, ts)
+		if err != nil {
+			t.Errorf("RenderDateTemplate(%q) returned error: %v", tt.text, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("RenderDateTemplate(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderDateTemplateInvalid(t *testing.T) {
+	ts := time.Date(2026, time.October, 17, 8, 30, 0, 0, time.UTC)
+
+	for _, text := range []string{"{{.Date", "{{.Unknown}}.md", "{{nope}}.md"} {
+		if _, err := RenderDateTemplate(text, ts); err == nil {
+			t.Errorf("Expected error for template %q", text)
+		}
+	}
+}
+
+func TestAddLineDateTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "project")
+	section := "## 💡 🧠 🔥 Fleeting Ideas"
+
+	err := AddLine(projectDir, "{{.Year}}/{{.Month}}", "{{.Date}}.md", section, "- New note", "after-heading", true, false)
+	if err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	// The note is created for the capture day, not a file literally named {{.Date}}.md
+	now := time.Now()
+	expectedPath := filepath.Join(projectDir, now.Format("2006"), now.Format("01"), now.Format("2006-01-02")+".md")
+	if _, err := os.Stat(expectedPath); err != nil {
+		// The day may have rolled over between AddLine and time.Now
+		yesterday := now.AddDate(0, 0, -1)
+		alt := filepath.Join(projectDir, yesterday.Format("2006"), yesterday.Format("01"), yesterday.Format("2006-01-02")+".md")
+		if _, altErr := os.Stat(alt); altErr != nil {
+			t.Errorf("Expected daily note at %s: %v", expectedPath, err)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package markdown
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// BlockKind identifies the kind of a block in a parsed document
+type BlockKind int
+
+const (
+	// BlockBlank is a run of blank lines
+	BlockBlank BlockKind = iota
+	// BlockFrontmatter is the YAML frontmatter delimited by --- lines at the top of the file
+	BlockFrontmatter
+	// BlockHeading is an ATX (# Heading) or setext (Heading followed by === or ---) heading
+	BlockHeading
+	// BlockCodeFence is a fenced code block, including its fences
+	BlockCodeFence
+	// BlockList is a run of list items with their nested items and continuation lines
+	BlockList
+	// BlockCallout is an Obsidian callout, a blockquote starting with > [!type]
+	BlockCallout
+	// BlockQuote is a blockquote
+	BlockQuote
+	// BlockTable is a pipe table
+	BlockTable
+	// BlockThematicBreak is a horizontal rule such as ---
+	BlockThematicBreak
+	// BlockHTML is an HTML block or comment, such as <!-- markin:insert -->
+	BlockHTML
+	// BlockParagraph is a paragraph of text
+	BlockParagraph
+)
+
+var blockKindNames = map[BlockKind]string{
+	BlockBlank:         "blank",
+	BlockFrontmatter:   "frontmatter",
+	BlockHeading:       "heading",
+	BlockCodeFence:     "code fence",
+	BlockList:          "list",
+	BlockCallout:       "callout",
+	BlockQuote:         "quote",
+	BlockTable:         "table",
+	BlockThematicBreak: "thematic break",
+	BlockHTML:          "html",
+	BlockParagraph:     "paragraph",
+}
+
+func (k BlockKind) String() string {
+	if name, ok := blockKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Block is a run of lines of the same kind. Start and End are line indexes,
+// End being exclusive.
+type Block struct {
+	Kind  BlockKind
+	Start int
+	End   int
+	// Level is the heading level (1-6) of heading blocks
+	Level int
+	// Text is the heading text of heading blocks, without markers
+	Text string
+}
+
+// Document is a markdown file parsed into blocks. Lines keep their exact text and
+// line endings so the document is written back byte-for-byte, apart from inserted lines.
+type Document struct {
+	Lines  []string
+	Blocks []Block
+
+	// endings holds the line ending of each line: "\n", "\r\n" or "" for a last
+	// line without trailing newline
+	endings []string
+	// eol is the line ending used for inserted lines
+	eol string
+}
+
+// Section is a heading and its content, which extends to the next heading of the
+// same or a higher level. Start is the heading's first line, Body the first line
+// after the heading and End the line after the section (exclusive).
+type Section struct {
+	Heading Block
+	Start   int
+	Body    int
+	End     int
+}
+
+var (
+	atxHeadingRe     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextRe         = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fenceRe          = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	listItemRe       = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])(?:[ \t]|$)`)
+	thematicBreakRe  = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	calloutRe        = regexp.MustCompile(`^ {0,3}>[ \t]*\[![^\]]+\]`)
+	blockquoteRe     = regexp.MustCompile(`^ {0,3}>`)
+	tableRe          = regexp.MustCompile(`^ {0,3}\|`)
+	htmlBlockStartRe = regexp.MustCompile(`^ {0,3}<(?:!--|[a-zA-Z/])`)
+)
+
+// Parse parses markdown content into a Document
+func Parse(content string) *Document {
+	doc := &Document{eol: "\n"}
+	for len(content) > 0 {
+		line, ending := content, ""
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			line, ending = content[:i], "\n"
+			if strings.HasSuffix(line, "\r") {
+				line, ending = line[:len(line)-1], "\r\n"
+			}
+			content = content[i+1:]
+		} else {
+			content = ""
+		}
+		doc.Lines = append(doc.Lines, line)
+		doc.endings = append(doc.endings, ending)
+	}
+
+	// Inserted lines follow the file's first line ending
+	if len(doc.endings) > 0 && doc.endings[0] != "" {
+		doc.eol = doc.endings[0]
+	}
+
+	doc.Blocks = parseBlocks(doc.Lines)
+	return doc
+}
+
+// parseBlocks splits lines into blocks
+func parseBlocks(lines []string) []Block {
+	var blocks []Block
+	i := 0
+
+	if end := frontmatterEnd(lines); end > 0 {
+		blocks = append(blocks, Block{Kind: BlockFrontmatter, Start: 0, End: end})
+		i = end
+	}
+
+	for i < len(lines) {
+		line := lines[i]
+		start := i
+
+		switch {
+		case isBlank(line):
+			for i < len(lines) && isBlank(lines[i]) {
+				i++
+			}
+			blocks = append(blocks, Block{Kind: BlockBlank, Start: start, End: i})
+
+		case fenceRe.MatchString(line):
+			i = fenceEnd(lines, i)
+			blocks = append(blocks, Block{Kind: BlockCodeFence, Start: start, End: i})
+
+		case atxHeadingRe.MatchString(line):
+			m := atxHeadingRe.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: BlockHeading, Start: start, End: i + 1, Level: len(m[1]), Text: strings.TrimSpace(m[2])})
+			i++
+
+		case thematicBreakRe.MatchString(line) && !listItemRe.MatchString(line):
+			blocks = append(blocks, Block{Kind: BlockThematicBreak, Start: start, End: i + 1})
+			i++
+
+		case listItemRe.MatchString(line):
+			i = listEnd(lines, i)
+			blocks = append(blocks, Block{Kind: BlockList, Start: start, End: i})
+
+		case blockquoteRe.MatchString(line):
+			kind := BlockQuote
+			if calloutRe.MatchString(line) {
+				kind = BlockCallout
+			}
+			for i < len(lines) && blockquoteRe.MatchString(lines[i]) {
+				i++
+			}
+			blocks = append(blocks, Block{Kind: kind, Start: start, End: i})
+
+		case tableRe.MatchString(line):
+			for i < len(lines) && tableRe.MatchString(lines[i]) {
+				i++
+			}
+			blocks = append(blocks, Block{Kind: BlockTable, Start: start, End: i})
+
+		case htmlBlockStartRe.MatchString(line):
+			i = htmlEnd(lines, i)
+			blocks = append(blocks, Block{Kind: BlockHTML, Start: start, End: i})
+
+		default:
+			i++
+			for i < len(lines) && !isBlank(lines[i]) && !interruptsParagraph(lines[i]) && !setextRe.MatchString(lines[i]) {
+				i++
+			}
+			// A paragraph followed by an === or --- underline is a setext heading
+			if i < len(lines) && setextRe.MatchString(lines[i]) {
+				level := 1
+				if strings.Contains(lines[i], "-") {
+					level = 2
+				}
+				text := make([]string, 0, i-start)
+				for _, l := range lines[start:i] {
+					text = append(text, strings.TrimSpace(l))
+				}
+				i++
+				blocks = append(blocks, Block{Kind: BlockHeading, Start: start, End: i, Level: level, Text: strings.Join(text, " ")})
+				continue
+			}
+			blocks = append(blocks, Block{Kind: BlockParagraph, Start: start, End: i})
+		}
+	}
+
+	return blocks
+}
+
+// frontmatterEnd returns the line after the closing frontmatter delimiter, or 0
+// when the document has no frontmatter
+func frontmatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t") != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimRight(lines[i], " \t"); l == "---" || l == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// fenceEnd returns the line after the fence closing the code block opened at start.
+// An unclosed fence runs to the end of the document.
+func fenceEnd(lines []string, start int) int {
+	m := fenceRe.FindStringSubmatch(lines[start])
+	fence := m[2]
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if len(lines[i])-len(trimmed) > 3 {
+			continue
+		}
+		rest := strings.TrimLeft(trimmed, fence[:1])
+		if len(trimmed)-len(rest) >= len(fence) && isBlank(rest) {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// listEnd returns the line after the list starting at start. Nested items, indented
+// continuation lines and lazy continuation lines belong to the list, as do blank lines
+// followed by another item or an indented line.
+func listEnd(lines []string, start int) int {
+	i := start + 1
+	for i < len(lines) {
+		line := lines[i]
+		switch {
+		case listItemRe.MatchString(line):
+			i++
+		case isBlank(line):
+			j := i
+			for j < len(lines) && isBlank(lines[j]) {
+				j++
+			}
+			if j < len(lines) && (listItemRe.MatchString(lines[j]) || isIndented(lines[j])) {
+				i = j
+				continue
+			}
+			return i
+		case isIndented(line):
+			if fenceRe.MatchString(strings.TrimLeft(line, " \t")) {
+				// A fence nested in a list item
+				i = nestedFenceEnd(lines, i)
+				continue
+			}
+			i++
+		case interruptsParagraph(line):
+			return i
+		default:
+			// Lazy continuation of the item's paragraph
+			i++
+		}
+	}
+	return i
+}
+
+// nestedFenceEnd returns the line after an indented code fence inside a list item
+func nestedFenceEnd(lines []string, start int) int {
+	indent := len(lines[start]) - len(strings.TrimLeft(lines[start], " \t"))
+	dedented := make([]string, 0, len(lines)-start)
+	for _, l := range lines[start:] {
+		if len(l)-len(strings.TrimLeft(l, " \t")) >= indent {
+			l = l[indent:]
+		}
+		dedented = append(dedented, l)
+	}
+	return start + fenceEnd(dedented, 0)
+}
+
+// htmlEnd returns the line after the HTML block starting at start
+func htmlEnd(lines []string, start int) int {
+	if strings.Contains(lines[start], "<!--") {
+		for i := start; i < len(lines); i++ {
+			if strings.Contains(lines[i], "-->") {
+				return i + 1
+			}
+		}
+		return len(lines)
+	}
+	i := start + 1
+	for i < len(lines) && !isBlank(lines[i]) {
+		i++
+	}
+	return i
+}
+
+// interruptsParagraph reports whether line starts a new block when it follows paragraph text
+func interruptsParagraph(line string) bool {
+	return atxHeadingRe.MatchString(line) ||
+		fenceRe.MatchString(line) ||
+		thematicBreakRe.MatchString(line) ||
+		blockquoteRe.MatchString(line) ||
+		(listItemRe.MatchString(line) && !isIndented(line)) ||
+		htmlBlockStartRe.MatchString(line)
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")
+}
+
+// Headings returns the heading blocks of the document in order
+func (d *Document) Headings() []Block {
+	var headings []Block
+	for _, b := range d.Blocks {
+		if b.Kind == BlockHeading {
+			headings = append(headings, b)
+		}
+	}
+	return headings
+}
+
+// Frontmatter returns the frontmatter lines without their delimiters
+func (d *Document) Frontmatter() ([]string, bool) {
+	if len(d.Blocks) == 0 || d.Blocks[0].Kind != BlockFrontmatter {
+		return nil, false
+	}
+	b := d.Blocks[0]
+	return d.Lines[b.Start+1 : b.End-1], true
+}
+
+// BlocksIn returns the blocks starting within the line range [start, end)
+func (d *Document) BlocksIn(start, end int) []Block {
+	var blocks []Block
+	for _, b := range d.Blocks {
+		if b.Start >= start && b.Start < end {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// ParseHeading parses a configured section such as "## 💡 Ideas" into its level
+// and text. Level is 0 when the section has no heading marker.
+func ParseHeading(section string) (level int, text string) {
+	if m := atxHeadingRe.FindStringSubmatch(strings.TrimSpace(section)); m != nil {
+		return len(m[1]), strings.TrimSpace(m[2])
+	}
+	return 0, strings.TrimSpace(section)
+}
+
+// FindSection returns the first section whose heading matches the configured
+// section. A section without heading marker matches headings of any level.
+func (d *Document) FindSection(section string) (Section, bool) {
+	return (&sectionMatcher{mode: MatchExact}).find(d, section)
+}
+
+// sectionAt returns the section introduced by heading h
+func (d *Document) sectionAt(h Block) Section {
+	end := len(d.Lines)
+	for _, b := range d.Blocks {
+		if b.Start > h.Start && b.Kind == BlockHeading && b.Level <= h.Level {
+			end = b.Start
+			break
+		}
+	}
+	return Section{Heading: h, Start: h.Start, Body: h.End, End: end}
+}
+
+// ContentEnd returns the line after the last non-blank line of the section
+func (d *Document) ContentEnd(s Section) int {
+	end := s.End
+	for end > s.Body && isBlank(d.Lines[end-1]) {
+		end--
+	}
+	return end
+}
+
+// Insert inserts lines before line index at and re-parses the document. Inserted
+// lines use the document's line ending; when appending to a document without
+// trailing newline, the new last line goes without one instead.
+func (d *Document) Insert(at int, lines ...string) {
+	endings := make([]string, len(lines))
+	for i := range endings {
+		endings[i] = d.eol
+	}
+	if at == len(d.Lines) && at > 0 && d.endings[at-1] == "" {
+		d.endings[at-1] = d.eol
+		endings[len(endings)-1] = ""
+	}
+
+	d.Lines = slices.Insert(d.Lines, at, lines...)
+	d.endings = slices.Insert(d.endings, at, endings...)
+	d.Blocks = parseBlocks(d.Lines)
+}
+
+// String renders the document with its original line endings
+func (d *Document) String() string {
+	var b strings.Builder
+	for i, line := range d.Lines {
+		b.WriteString(line)
+		b.WriteString(d.endings[i])
+	}
+	return b.String()
+}
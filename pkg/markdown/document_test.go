@@ -0,0 +1,397 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBlocks(t *testing.T) {
+	content := `---
+title: Daily
+tags: [daily]
+---
+# 2026-10-17
+
+Intro paragraph
+spanning two lines
+
+## Tasks
+- [ ] first
+  - nested
+- [ ] second
+
+` + "```go" + `
+## not a heading
+` + "```" + `
+
+> [!note] Callout
+> body
+
+> plain quote
+
+| a | b |
+| - | - |
+
+<!-- markin:insert -->
+
+***
+
+Setext Heading
+--------------
+`
+
+	doc := Parse(content)
+
+	expected := []struct {
+		kind  BlockKind
+		start int
+		end   int
+	}{
+		{BlockFrontmatter, 0, 4},
+		{BlockHeading, 4, 5},
+		{BlockBlank, 5, 6},
+		{BlockParagraph, 6, 8},
+		{BlockBlank, 8, 9},
+		{BlockHeading, 9, 10},
+		{BlockList, 10, 13},
+		{BlockBlank, 13, 14},
+		{BlockCodeFence, 14, 17},
+		{BlockBlank, 17, 18},
+		{BlockCallout, 18, 20},
+		{BlockBlank, 20, 21},
+		{BlockQuote, 21, 22},
+		{BlockBlank, 22, 23},
+		{BlockTable, 23, 25},
+		{BlockBlank, 25, 26},
+		{BlockHTML, 26, 27},
+		{BlockBlank, 27, 28},
+		{BlockThematicBreak, 28, 29},
+		{BlockBlank, 29, 30},
+		{BlockHeading, 30, 32},
+	}
+
+	if len(doc.Blocks) != len(expected) {
+		for _, b := range doc.Blocks {
+			t.Logf("%s [%d, %d)", b.Kind, b.Start, b.End)
+		}
+		t.Fatalf("Expected %d blocks, got %d", len(expected), len(doc.Blocks))
+	}
+	for i, e := range expected {
+		b := doc.Blocks[i]
+		if b.Kind != e.kind || b.Start != e.start || b.End != e.end {
+			t.Errorf("Block %d: expected %s [%d, %d), got %s [%d, %d)", i, e.kind, e.start, e.end, b.Kind, b.Start, b.End)
+		}
+	}
+
+	headings := doc.Headings()
+	if len(headings) != 3 {
+		t.Fatalf("Expected 3 headings, got %d", len(headings))
+	}
+	if headings[0].Level != 1 || headings[0].Text != "2026-10-17" {
+		t.Errorf("Unexpected first heading: %+v", headings[0])
+	}
+	if headings[2].Level != 2 || headings[2].Text != "Setext Heading" {
+		t.Errorf("Unexpected setext heading: %+v", headings[2])
+	}
+
+	fm, ok := doc.Frontmatter()
+	if !ok || len(fm) != 2 || fm[0] != "title: Daily" {
+		t.Errorf("Unexpected frontmatter: %v", fm)
+	}
+}
+
+func TestParseHeading(t *testing.T) {
+	tests := []struct {
+		section string
+		level   int
+		text    string
+	}{
+		{"## 💡 🧠 🔥 Fleeting Ideas", 2, "💡 🧠 🔥 Fleeting Ideas"},
+		{"# Title #", 1, "Title"},
+		{"### Log  ", 3, "Log"},
+		{"Tasks", 0, "Tasks"},
+	}
+
+	for _, tt := range tests {
+		level, text := ParseHeading(tt.section)
+		if level != tt.level || text != tt.text {
+			t.Errorf("ParseHeading(%q) = %d, %q, expected %d, %q", tt.section, level, text, tt.level, tt.text)
+		}
+	}
+}
+
+func TestFindSectionHierarchy(t *testing.T) {
+	content := `# Day
+## Log
+- entry
+### Morning
+- coffee
+## Review
+- done
+# Appendix
+`
+	doc := Parse(content)
+
+	sec, ok := doc.FindSection("## Log")
+	if !ok {
+		t.Fatal("Expected to find the Log section")
+	}
+	// The sub-heading belongs to the section, the next level 2 heading ends it
+	if sec.Start != 1 || sec.Body != 2 || sec.End != 5 {
+		t.Errorf("Unexpected Log section bounds: %+v", sec)
+	}
+
+	sec, ok = doc.FindSection("## Review")
+	if !ok || sec.End != 7 {
+		t.Errorf("Expected the Review section to end at the level 1 heading, got %+v", sec)
+	}
+
+	sec, ok = doc.FindSection("# Day")
+	if !ok || sec.End != 7 {
+		t.Errorf("Expected the Day section to end at the next level 1 heading, got %+v", sec)
+	}
+
+	if _, ok := doc.FindSection("### Log"); ok {
+		t.Error("Expected a level mismatch not to match")
+	}
+	if _, ok := doc.FindSection("Morning"); !ok {
+		t.Error("Expected a section without marker to match any level")
+	}
+}
+
+func writeTestNote(t *testing.T, content string) (string, string) {
+	t.Helper()
+	projectDir := t.TempDir()
+	filePath := filepath.Join(projectDir, "notes", "test.md")
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return projectDir, filePath
+}
+
+func TestAddLineIgnoresHeadingsInCodeFences(t *testing.T) {
+	content := "## Ideas\n- Existing\n\n```md\n## Other\n```\n\n## Other\n- Other note\n"
+	projectDir, filePath := writeTestNote(t, content)
+
+	if err := AddLine(projectDir, "notes", "test.md", "## Ideas", "- New", "before-end", false, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	updated, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	// The fenced "## Other" is part of the Ideas section
+	expected := "## Ideas\n- Existing\n\n```md\n## Other\n```\n- New\n\n## Other\n- Other note\n"
+	if string(updated) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, updated)
+	}
+}
+
+func TestAddLineSectionMentionedInText(t *testing.T) {
+	content := "# Day\nRemember to fill in ## Ideas later\n"
+	projectDir, _ := writeTestNote(t, content)
+
+	// A section name mentioned in body text is not a section
+	err := AddLine(projectDir, "notes", "test.md", "## Ideas", "- New", "after-heading", false, false)
+	if err == nil {
+		t.Error("Expected error for missing section")
+	}
+}
+
+func TestAddLineBeforeEndIncludesSubsections(t *testing.T) {
+	content := "## Log\n- entry\n### Morning\n- coffee\n\n## Review\n- done\n"
+	projectDir, filePath := writeTestNote(t, content)
+
+	if err := AddLine(projectDir, "notes", "test.md", "## Log", "- New", "before-end", false, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	updated, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	expected := "## Log\n- entry\n### Morning\n- coffee\n- New\n\n## Review\n- done\n"
+	if string(updated) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, updated)
+	}
+}
+
+func TestAddLineSetextHeading(t *testing.T) {
+	content := "Ideas\n-----\n- Existing\n"
+	projectDir, filePath := writeTestNote(t, content)
+
+	if err := AddLine(projectDir, "notes", "test.md", "## Ideas", "- New", "after-heading", false, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	updated, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	expected := "Ideas\n-----\n- New\n- Existing\n"
+	if string(updated) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, updated)
+	}
+}
+
+// corpusSection is the section every note in testdata/corpus contains
+const corpusSection = "## 📝 Log"
+
+func readCorpus(t *testing.T) map[string]string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.md"))
+	if err != nil {
+		t.Fatalf("Failed to list corpus: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("Corpus is empty")
+	}
+
+	corpus := make(map[string]string, len(paths))
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", p, err)
+		}
+		corpus[filepath.Base(p)] = string(content)
+	}
+	return corpus
+}
+
+// onlyAdded reports whether after equals before with only the given lines (or blank
+// lines) added. The last line of before may gain a line ending when lines are appended.
+func onlyAdded(before, after string, added ...string) bool {
+	b := strings.SplitAfter(before, "\n")
+	a := strings.SplitAfter(after, "\n")
+	i := 0
+	for _, line := range a {
+		if line == "" {
+			continue
+		}
+		switch {
+		case i < len(b) && b[i] == line:
+			i++
+		case i == len(b)-1 && !strings.HasSuffix(b[i], "\n") && strings.TrimRight(line, "\r\n") == b[i]:
+			i++
+		case len(added) > 0 && strings.TrimRight(line, "\r\n") == added[0]:
+			added = added[1:]
+		case strings.TrimSpace(line) == "":
+		default:
+			return false
+		}
+	}
+	return len(added) == 0 && (i == len(b) || (i == len(b)-1 && b[i] == ""))
+}
+
+func TestCorpusRoundTrip(t *testing.T) {
+	for name, content := range readCorpus(t) {
+		if got := Parse(content).String(); got != content {
+			t.Errorf("%s: round trip changed the content.\nExpected:\n%q\nGot:\n%q", name, content, got)
+		}
+	}
+}
+
+func TestCorpusMinimalDiff(t *testing.T) {
+	const entry = "- ⚡ *10:00:00 am:* **Fleeting**:: New entry"
+	entries := [][]string{
+		{entry},
+		{entry, "  continued on a second line", "  - and a nested item"},
+	}
+
+	for name, content := range readCorpus(t) {
+		for _, position := range []Position{PositionAfterHeading, PositionBeforeEnd} {
+			for _, lines := range entries {
+				doc := Parse(content)
+				sec, ok := doc.FindSection(corpusSection)
+				if !ok {
+					t.Fatalf("%s: section %q not found", name, corpusSection)
+				}
+
+				addLineInSection(doc, sec, lines, Options{Position: position})
+				got := doc.String()
+				if !strings.Contains(got, entry) {
+					t.Errorf("%s (%s): entry missing:\n%q", name, position, got)
+				}
+				if !onlyAdded(content, got, lines...) {
+					t.Errorf("%s (%s): insertion changed other lines.\nBefore:\n%q\nAfter:\n%q", name, position, content, got)
+				}
+				if strings.Contains(content, "\r\n") && !strings.Contains(got, strings.Join(lines, "\r\n")+"\r\n") {
+					t.Errorf("%s (%s): expected the entry to use CRLF line endings:\n%q", name, position, got)
+				}
+			}
+		}
+
+		doc := Parse(content)
+		appendSection(doc, []string{"- entry"}, Options{Section: "## New Section"})
+		got := doc.String()
+		if !onlyAdded(content, got, "## New Section", "- entry") {
+			t.Errorf("%s: appending a section changed other lines.\nBefore:\n%q\nAfter:\n%q", name, content, got)
+		}
+	}
+}
+
+func TestAddLinePreservesFileStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		position string
+		expected string
+	}{
+		{
+			name:     "crlf line endings",
+			content:  "## Log\r\n- a\r\n\r\n## Other\r\n",
+			position: "before-end",
+			expected: "## Log\r\n- a\r\n- New\r\n\r\n## Other\r\n",
+		},
+		{
+			name:     "no trailing newline",
+			content:  "## Log\n- a",
+			position: "before-end",
+			expected: "## Log\n- a\n- New",
+		},
+		{
+			name:     "blank line after heading",
+			content:  "## Log\n\n- a\n",
+			position: "after-heading",
+			expected: "## Log\n\n- New\n- a\n",
+		},
+		{
+			name:     "consecutive blank lines",
+			content:  "## Log\n- a\n\n\n\n## Other\n",
+			position: "before-end",
+			expected: "## Log\n- a\n- New\n\n\n\n## Other\n",
+		},
+		{
+			name:     "paragraph after heading",
+			content:  "## Log\nSome text\n",
+			position: "after-heading",
+			expected: "## Log\n- New\n\nSome text\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir, filePath := writeTestNote(t, tt.content)
+			if err := AddLine(projectDir, "notes", "test.md", "## Log", "- New", tt.position, false, false); err != nil {
+				t.Fatalf("Failed to add line: %v", err)
+			}
+
+			updated, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(updated) != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSectionNotFound is returned when the note lacks the section and creating it
+	// is not allowed. The error is a *SectionNotFoundError.
+	ErrSectionNotFound = errors.New("section not found")
+	// ErrUnexpandedVariable is returned when a note path still contains an environment
+	// variable after expansion, usually because the variable is not set
+	ErrUnexpandedVariable = errors.New("path contains unexpanded environment variables")
+	// ErrInvalidPosition is returned for a Position that is not one of Positions
+	ErrInvalidPosition = errors.New("invalid position")
+)
+
+// SectionNotFoundError reports the section missing from a note
+type SectionNotFoundError struct {
+	Section string
+	// Path is the note's path, empty for documents not backed by a file
+	Path string
+}
+
+func (e *SectionNotFoundError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("section '%s' not found and create_section_if_missing is false", e.Section)
+	}
+	return fmt.Sprintf("section '%s' not found in file at %s and create_section_if_missing is false", e.Section, e.Path)
+}
+
+// Is makes errors.Is(err, ErrSectionNotFound) match
+func (e *SectionNotFoundError) Is(target error) bool {
+	return target == ErrSectionNotFound
+}
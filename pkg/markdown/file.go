@@ -0,0 +1,282 @@
+package markdown
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// defaultFileMode is the permission of newly created notes
+const defaultFileMode os.FileMode = 0644
+
+// maxUpdateAttempts bounds how often an update is re-applied when the file keeps
+// changing underneath it
+const maxUpdateAttempts = 5
+
+// ErrConcurrentModification is returned when a file kept being modified by another
+// application while markin was trying to update it
+var ErrConcurrentModification = errors.New("file was modified concurrently")
+
+// updateFile applies update to the file at fullPath in fsys. On the OS filesystem an
+// advisory lock on the file is held across the read and the write so concurrent
+// markin invocations cannot lose entries, and the new content is written atomically
+// so a crash never leaves a truncated note behind.
+func updateFile(ctx context.Context, fsys afero.Fs, fullPath string, debug bool, update UpdateFunc) error {
+	// Replacing a symlinked note would turn the link into a copy
+	fullPath, err := resolveLinks(fsys, fullPath)
+	if err != nil {
+		return err
+	}
+
+	debugPrint(debug, "Debug: Creating directory structure for: %s\n", filepath.Dir(fullPath))
+	if err := fsys.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, created, err := openLocked(ctx, fsys, fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", fullPath, err)
+	}
+	// The mode of a file just created reflects the umask
+	info, err := f.Stat()
+	if err != nil {
+		closeLocked(f)
+		return err
+	}
+	perm := info.Mode().Perm()
+	if holdsLock {
+		defer closeLocked(f)
+	} else if err := f.Close(); err != nil {
+		// Without a lock there is nothing to hold the file open for
+		return err
+	}
+
+	written := false
+	defer func() {
+		// Don't leave behind the empty file created to hold the lock
+		if created && !written {
+			fsys.Remove(fullPath)
+		}
+	}()
+
+	// Other applications (Obsidian, sync clients) don't honor the lock, so the file is
+	// checked again before writing and the update re-applied if it changed meanwhile
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		before, content, err := readSnapshot(fsys, fullPath)
+		if err != nil {
+			return err
+		}
+
+		newContent, err := update(content, len(content) > 0)
+		if err != nil {
+			return err
+		}
+
+		after, _, err := readSnapshot(fsys, fullPath)
+		if err != nil {
+			return err
+		}
+		if !before.equal(after) {
+			if attempt >= maxUpdateAttempts {
+				return fmt.Errorf("%w: %s changed %d times while being updated", ErrConcurrentModification, fullPath, attempt)
+			}
+			debugPrint(debug, "Debug: File changed since it was read, re-applying (attempt %d)\n", attempt+1)
+			continue
+		}
+
+		debugPrint(debug, "Debug: Writing content to file: %s\n", fullPath)
+		if err := writeFileAtomic(fsys, fullPath, newContent, perm); err != nil {
+			return err
+		}
+		written = true
+		break
+	}
+
+	warnConflictCopies(fsys, fullPath)
+	return nil
+}
+
+// resolveLinks returns path with the symlinks of the OS filesystem resolved, so the
+// note a link points to is updated rather than the link replaced. A note that
+// doesn't exist yet keeps its path.
+func resolveLinks(fsys afero.Fs, path string) (string, error) {
+	if _, ok := fsys.(*afero.OsFs); !ok {
+		return path, nil
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	return resolved, nil
+}
+
+// snapshot identifies a version of a file's content
+type snapshot struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+func (s snapshot) equal(other snapshot) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size && s.hash == other.hash
+}
+
+// readSnapshot reads the file at path and records its modification time, size and hash
+func readSnapshot(fsys afero.Fs, path string) (snapshot, []byte, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return snapshot{}, nil, err
+	}
+	content, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return snapshot{}, nil, err
+	}
+	return snapshot{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    sha256.Sum256(content),
+	}, content, nil
+}
+
+// conflictCopies returns the sync conflict copies of the note at path, such as
+// "daily (conflict).md", "daily (Jane's conflicted copy 2026-10-17).md" (Dropbox,
+// Nextcloud, Obsidian Sync) or "daily.sync-conflict-20261017-101010-ABCDEFG.md" (Syncthing)
+func conflictCopies(fsys afero.Fs, path string) ([]string, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+
+	entries, err := afero.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var copies []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == filepath.Base(path) {
+			continue
+		}
+		if !strings.HasPrefix(name, base) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		middle := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(name, base), ext))
+		if (strings.HasPrefix(middle, " (") || strings.HasPrefix(middle, ".sync-conflict")) && strings.Contains(middle, "conflict") {
+			copies = append(copies, filepath.Join(dir, name))
+		}
+	}
+	return copies, nil
+}
+
+// warnConflictCopies warns about sync conflict copies of the note at path, since
+// entries captured into the original won't show up in them
+func warnConflictCopies(fsys afero.Fs, path string) {
+	copies, err := conflictCopies(fsys, path)
+	if err != nil {
+		return
+	}
+	for _, c := range copies {
+		warnPrint("Warning: found sync conflict copy %s of %s, entries may need to be merged by hand\n", c, path)
+	}
+}
+
+// openLocked opens the file at path, creating it if needed, and takes an exclusive
+// advisory lock on it. Since writes replace the file by renaming, the lock is only
+// kept once the locked descriptor still refers to the file at path; otherwise
+// another process replaced it while we waited and we try again. Files that are not
+// on the OS filesystem are not locked.
+func openLocked(ctx context.Context, fsys afero.Fs, path string) (f afero.File, created bool, err error) {
+	for {
+		f, err = fsys.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, defaultFileMode)
+		created = err == nil
+		if errors.Is(err, fs.ErrExist) {
+			f, err = fsys.OpenFile(path, os.O_RDWR, 0)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed between the two opens, start over
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		osFile, ok := f.(*os.File)
+		if !ok {
+			return f, created, nil
+		}
+
+		if err := lockFile(ctx, osFile); err != nil {
+			f.Close()
+			return nil, false, err
+		}
+
+		locked, statErr := f.Stat()
+		current, err := fsys.Stat(path)
+		if statErr == nil && err == nil && os.SameFile(locked, current) {
+			return f, created, nil
+		}
+
+		unlockFile(osFile)
+		f.Close()
+		if statErr != nil {
+			return nil, false, statErr
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, false, err
+		}
+	}
+}
+
+// closeLocked releases the lock taken by openLocked and closes f
+func closeLocked(f afero.File) {
+	if osFile, ok := f.(*os.File); ok {
+		unlockFile(osFile)
+	}
+	f.Close()
+}
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it and renames
+// it over path, so readers see either the old or the new content but never a partial write
+func writeFileAtomic(fsys afero.Fs, path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := afero.TempFile(fsys, dir, "."+filepath.Base(path)+".markin-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			fsys.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = fsys.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = fsys.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(fsys, dir)
+}
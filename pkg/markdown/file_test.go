@@ -0,0 +1,285 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestAddLineConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "project")
+	section := "## 💡 🧠 🔥 Fleeting Ideas"
+
+	// Fire many captures at once, as hotkeys pressed in quick succession would
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			line := fmt.Sprintf("- Note %d", i)
+			errs <- AddLine(projectDir, "notes", "test.md", section, line, "before-end", true, false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Failed to add line: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(projectDir, "notes", "test.md"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	for i := range writers {
+		if !strings.Contains(string(content), fmt.Sprintf("- Note %d\n", i)) {
+			t.Errorf("Entry %d was lost:\n%s", i, content)
+		}
+	}
+	if n := strings.Count(string(content), section); n != 1 {
+		t.Errorf("Expected the section once, found it %d times:\n%s", n, content)
+	}
+}
+
+func TestAddLinePreservesPermissions(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "notes", "test.md")
+	section := "## Notes"
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte(section+"\n- Existing\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	// WriteFile is subject to the umask, set the mode explicitly
+	if err := os.Chmod(filePath, 0600); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+
+	if err := AddLine(tmpDir, "notes", "test.md", section, "- New", "after-heading", false, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 to be preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestAddLineThroughSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "archive", "2026-10-17.md")
+	link := filepath.Join(tmpDir, "notes", "today.md")
+	for _, dir := range []string{filepath.Dir(target), filepath.Dir(link)} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(target, []byte("## Notes\n- Existing\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	if err := AddLine(tmpDir, "notes", "today.md", "## Notes", "- New", "before-end", false, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("Failed to stat link: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the note to still be a symlink, got mode %v", info.Mode())
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read target: %v", err)
+	}
+	expected := "## Notes\n- Existing\n- New\n"
+	if string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+	entries, err := os.ReadDir(filepath.Dir(link))
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the link in its directory, got %d entries", len(entries))
+	}
+}
+
+func TestAddLineLeavesNoTempFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	section := "## Notes"
+
+	if err := AddLine(tmpDir, "notes", "test.md", section, "- New", "after-heading", true, false); err != nil {
+		t.Fatalf("Failed to add line: %v", err)
+	}
+
+	// A failed update must not leave a temp file behind either
+	if err := AddLine(tmpDir, "notes", "test.md", "## Missing", "- New", "after-heading", false, false); err == nil {
+		t.Fatal("Expected error for missing section")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(tmpDir, "notes"))
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "test.md" {
+		t.Errorf("Unexpected files in note directory: %v", names)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.md")
+
+	if err := os.WriteFile(filePath, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := writeFileAtomic(afero.NewOsFs(), filePath, []byte("new"), 0640); err != nil {
+		t.Fatalf("Failed to write atomically: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "new" {
+		t.Errorf("Expected new content, got %q", content)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected permissions 0640, got %o", info.Mode().Perm())
+	}
+}
+
+func TestUpdateFileReappliesAfterExternalEdit(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(filePath, []byte("## Notes\n- Existing\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	calls := 0
+	err := updateFile(context.Background(), afero.NewOsFs(), filePath, false, func(content []byte, exists bool) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			// Simulate Obsidian saving the note between markin's read and write
+			if err := os.WriteFile(filePath, []byte("## Notes\n- Existing\n- From Obsidian\n"), 0644); err != nil {
+				t.Fatalf("Failed to simulate external edit: %v", err)
+			}
+		}
+		return append(content, []byte("- From markin\n")...), nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to update file: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected the update to be re-applied once, got %d calls", calls)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	expected := "## Notes\n- Existing\n- From Obsidian\n- From markin\n"
+	if string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+}
+
+func TestUpdateFileGivesUpOnContinuousEdits(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(filePath, []byte("## Notes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	calls := 0
+	err := updateFile(context.Background(), afero.NewOsFs(), filePath, false, func(content []byte, exists bool) ([]byte, error) {
+		calls++
+		edit := fmt.Sprintf("## Notes\n- External edit %d\n", calls)
+		if err := os.WriteFile(filePath, []byte(edit), 0644); err != nil {
+			t.Fatalf("Failed to simulate external edit: %v", err)
+		}
+		return append(content, []byte("- From markin\n")...), nil
+	})
+	if !errors.Is(err, ErrConcurrentModification) {
+		t.Fatalf("Expected ErrConcurrentModification, got %v", err)
+	}
+	if calls != maxUpdateAttempts {
+		t.Errorf("Expected %d attempts, got %d", maxUpdateAttempts, calls)
+	}
+
+	// The other writer's content must not be clobbered
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if strings.Contains(string(content), "From markin") {
+		t.Errorf("Expected the external edit to be kept, got %q", content)
+	}
+}
+
+func TestConflictCopies(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	dir := "/vault/daily"
+	names := []string{
+		"2026-10-17.md",
+		"2026-10-17 (conflict).md",
+		"2026-10-17 (Jane's conflicted copy 2026-10-17).md",
+		"2026-10-17.sync-conflict-20261017-101010-ABCDEFG.md",
+		"2026-10-17 (copy).md",
+		"2026-10-17-review.md",
+		"2026-10-18 (conflict).md",
+	}
+	for _, name := range names {
+		if err := afero.WriteFile(fsys, filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	copies, err := conflictCopies(fsys, filepath.Join(dir, "2026-10-17.md"))
+	if err != nil {
+		t.Fatalf("Failed to list conflict copies: %v", err)
+	}
+
+	var got []string
+	for _, c := range copies {
+		got = append(got, filepath.Base(c))
+	}
+	expected := []string{
+		"2026-10-17 (Jane's conflicted copy 2026-10-17).md",
+		"2026-10-17 (conflict).md",
+		"2026-10-17.sync-conflict-20261017-101010-ABCDEFG.md",
+	}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected conflict copies %v, got %v", expected, got)
+	}
+}
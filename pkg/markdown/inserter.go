@@ -0,0 +1,151 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Inserter adds entries to notes. The zero value is not usable, create one with
+// NewInserter.
+type Inserter struct {
+	opts  Options
+	store Store
+	now   func() time.Time
+}
+
+// Option configures an Inserter
+type Option func(*Inserter)
+
+// NewInserter returns an Inserter writing to the local disk, configured by opts
+func NewInserter(opts ...Option) *Inserter {
+	in := &Inserter{now: time.Now}
+	for _, opt := range opts {
+		opt(in)
+	}
+	return in
+}
+
+// WithOptions replaces all insertion options
+func WithOptions(opts Options) Option {
+	return func(in *Inserter) {
+		in.opts = opts
+	}
+}
+
+// WithSection sets the section entries go under
+func WithSection(section string) Option {
+	return func(in *Inserter) {
+		in.opts.Section = section
+	}
+}
+
+// WithPosition sets where entries go within their section
+func WithPosition(position Position) Option {
+	return func(in *Inserter) {
+		in.opts.Position = position
+	}
+}
+
+// WithCreateSectionIfMissing sets whether a missing section is created
+func WithCreateSectionIfMissing(create bool) Option {
+	return func(in *Inserter) {
+		in.opts.CreateSectionIfMissing = create
+	}
+}
+
+// WithTemplate sets the template new notes are created from
+func WithTemplate(path string) Option {
+	return func(in *Inserter) {
+		in.opts.Template = path
+	}
+}
+
+// WithStore sets the filesystem notes are read from and written to, LocalStore by default
+func WithStore(store Store) Option {
+	return func(in *Inserter) {
+		in.store = store
+	}
+}
+
+// WithClock sets the clock entries are timed with when Options.Time is zero
+func WithClock(now func() time.Time) Option {
+	return func(in *Inserter) {
+		in.now = now
+	}
+}
+
+// WithDebug enables debug output
+func WithDebug(debug bool) Option {
+	return func(in *Inserter) {
+		in.opts.Debug = debug
+	}
+}
+
+// Insert adds entry to the note at path, creating the note and the section as
+// needed. opts apply to this insertion only.
+func (in *Inserter) Insert(ctx context.Context, path, entry string, opts ...Option) error {
+	c := *in
+	for _, opt := range opts {
+		opt(&c)
+	}
+	o := c.opts
+
+	if entry == "" {
+		return nil
+	}
+	// Catch invalid options before touching the note
+	if err := checkPosition(o); err != nil {
+		return err
+	}
+	if _, err := newSectionMatcher(o.Match, o.Section, o.SectionAliases); err != nil {
+		return err
+	}
+	if o.Time.IsZero() {
+		o.Time = c.now()
+	}
+	store := c.store
+	if store == nil {
+		store = LocalStore{Debug: o.Debug}
+	}
+
+	return store.Update(ctx, path, func(content []byte, exists bool) ([]byte, error) {
+		opts := o
+		if !exists {
+			debugPrint(o.Debug, "Debug: File does not exist, creating it\n")
+			if o.Template != "" {
+				var err error
+				if content, err = newFromTemplate(ctx, store, path, o); err != nil {
+					return nil, err
+				}
+			}
+			// A new note gets the section even when existing notes must have it
+			opts.CreateSectionIfMissing = true
+		}
+
+		doc := Parse(string(content))
+		if err := doc.AddEntry(entry, opts); err != nil {
+			var notFound *SectionNotFoundError
+			if errors.As(err, &notFound) {
+				notFound.Path = path
+			}
+			return nil, err
+		}
+		return []byte(doc.String()), nil
+	})
+}
+
+// newFromTemplate returns the content of a new note rendered from opts.Template
+func newFromTemplate(ctx context.Context, store Store, path string, opts Options) ([]byte, error) {
+	debugPrint(opts.Debug, "Debug: Creating note from template: %s\n", opts.Template)
+	tmpl, err := store.ReadFile(ctx, opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return []byte(RenderNoteTemplate(string(tmpl), opts.Time, title)), nil
+}
@@ -0,0 +1,148 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store
+type memStore map[string]string
+
+func (s memStore) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	content, ok := s[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func (s memStore) Update(ctx context.Context, path string, update UpdateFunc) error {
+	content, ok := s[path]
+	updated, err := update([]byte(content), ok && content != "")
+	if err != nil {
+		return err
+	}
+	s[path] = string(updated)
+	return nil
+}
+
+func TestInserter(t *testing.T) {
+	clock := func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		files    memStore
+		opts     []Option
+		callOpts []Option
+		expected string
+	}{
+		{
+			name:     "options",
+			files:    memStore{"day.md": "## Log\n- a\n\n## Other\n- b\n"},
+			opts:     []Option{WithSection("## Log"), WithPosition(PositionAfterHeading)},
+			expected: "## Log\n- New\n- a\n\n## Other\n- b\n",
+		},
+		{
+			name:     "per-call options override",
+			files:    memStore{"day.md": "## Log\n- a\n\n## Other\n- b\n"},
+			opts:     []Option{WithSection("## Log")},
+			callOpts: []Option{WithSection("## Other")},
+			expected: "## Log\n- a\n\n## Other\n- b\n- New\n",
+		},
+		{
+			name:     "clock orders sorted entries",
+			files:    memStore{"day.md": "## Log\n- 09:00 a\n- 15:00 b\n"},
+			opts:     []Option{WithSection("## Log"), WithPosition(PositionSorted), WithClock(clock)},
+			expected: "## Log\n- 09:00 a\n- New\n- 15:00 b\n",
+		},
+		{
+			name:     "new note from template",
+			files:    memStore{"tmpl.md": "# {{title}} {{date:YYYY-MM-DD}}\n\n## Log\n"},
+			opts:     []Option{WithSection("## Log"), WithTemplate("tmpl.md"), WithClock(clock)},
+			expected: "# day 2026-10-17\n\n## Log\n- New\n",
+		},
+		{
+			name:     "missing section created",
+			files:    memStore{"day.md": "## Other\n- b\n"},
+			opts:     []Option{WithSection("## Log"), WithCreateSectionIfMissing(true)},
+			expected: "## Other\n- b\n\n## Log\n- New\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInserter(append(tt.opts, WithStore(tt.files))...)
+			if err := in.Insert(context.Background(), "day.md", "- New", tt.callOpts...); err != nil {
+				t.Fatalf("Failed to insert entry: %v", err)
+			}
+			if got := tt.files["day.md"]; got != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInserterErrors(t *testing.T) {
+	files := memStore{"day.md": "## Other\n- b\n"}
+	in := NewInserter(WithStore(files), WithSection("## Log"))
+
+	err := in.Insert(context.Background(), "day.md", "- New")
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Fatalf("Expected ErrSectionNotFound, got %v", err)
+	}
+	var notFound *SectionNotFoundError
+	if !errors.As(err, &notFound) || notFound.Section != "## Log" || notFound.Path != "day.md" {
+		t.Errorf("Expected *SectionNotFoundError for ## Log in day.md, got %#v", notFound)
+	}
+
+	if err := in.Insert(context.Background(), "day.md", "- New", WithPosition("middle")); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("Expected ErrInvalidPosition, got %v", err)
+	}
+	if err := in.Insert(context.Background(), "day.md", "- New", WithPosition(PositionUnderSubheading)); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("Expected ErrInvalidPosition without a subheading, got %v", err)
+	}
+	if files["day.md"] != "## Other\n- b\n" {
+		t.Errorf("Expected the note to be left untouched, got %q", files["day.md"])
+	}
+
+	if _, err := ResolvePath("/vault", "notes$", "note.md", time.Now(), false); !errors.Is(err, ErrUnexpandedVariable) {
+		t.Errorf("Expected ErrUnexpandedVariable, got %v", err)
+	}
+}
+
+func TestInserterCanceled(t *testing.T) {
+	_, filePath := writeTestNote(t, "## Log\n- a\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewInserter(WithSection("## Log")).Insert(ctx, filePath, "- New"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "## Log\n- a\n" {
+		t.Errorf("Expected the note to be unchanged, got %q", content)
+	}
+}
+
+func TestDocumentAddEntry(t *testing.T) {
+	doc := Parse("# Day\n\n## Log\n- a\n")
+	if err := doc.AddEntry("- New\n  details", Options{Section: "## Log"}); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+	expected := "# Day\n\n## Log\n- a\n- New\n  details\n"
+	if got := doc.String(); got != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, got)
+	}
+
+	err := doc.AddEntry("- New", Options{Section: "## Missing"})
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Expected ErrSectionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package markdown
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// holdsLock is false where lockFile is a no-op: the note is closed before it is
+// replaced, since Windows cannot rename over a file that is still open
+const holdsLock = false
+
+// lockFile is a no-op on platforms without flock. Writes are still atomic, so a
+// crash cannot truncate a note, but concurrent invocations are not serialized.
+func lockFile(ctx context.Context, f *os.File) error {
+	return nil
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// syncDir is a no-op where directories cannot be synced
+func syncDir(fsys afero.Fs, dir string) error {
+	return nil
+}
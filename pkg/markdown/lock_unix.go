@@ -0,0 +1,57 @@
+//go:build unix
+
+package markdown
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// holdsLock is true where lockFile locks, so the note is kept open until it is
+// replaced to hold the lock across the update
+const holdsLock = true
+
+// lockPollInterval is how often lockFile retries while another process holds the lock
+const lockPollInterval = 10 * time.Millisecond
+
+// lockFile takes an exclusive advisory lock on f, waiting until it is available or
+// ctx is done
+func lockFile(ctx context.Context, f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+		default:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// syncDir flushes the directory entry of a renamed file to disk
+func syncDir(fsys afero.Fs, dir string) error {
+	d, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package markdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestUpdateFileRespectsUmask(t *testing.T) {
+	old := syscall.Umask(077)
+	defer syscall.Umask(old)
+
+	filePath := filepath.Join(t.TempDir(), "daily", "note.md")
+	err := updateFile(context.Background(), afero.NewOsFs(), filePath, false, func(content []byte, exists bool) ([]byte, error) {
+		return []byte("## Notes\n- Entry\n"), nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to update file: %v", err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 under umask 077, got %o", info.Mode().Perm())
+	}
+}
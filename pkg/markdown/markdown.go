@@ -1,15 +1,24 @@
 package markdown
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
-// expandPath expands environment variables in a path
-func expandPath(path string) string {
-	return os.ExpandEnv(path)
+// ExpandPath expands environment variables and a leading ~ in a path
+func ExpandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
 }
 
 // debugPrint prints debug information only when debug is enabled
@@ -19,229 +28,291 @@ func debugPrint(debug bool, format string, args ...any) {
 	}
 }
 
+// warnPrint prints warnings to stderr so they don't mix with command output
+func warnPrint(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+// Options controls where and how an entry is inserted into a note
+type Options struct {
+	// Section is the heading the entry goes under, e.g. "## 💡 Ideas"
+	Section string
+	// Match is the section matching mode, one of the Match constants, MatchExact
+	// when empty. It applies to Section and the sections of SectionOrder.
+	Match string
+	// SectionAliases lists other headings each configured section may appear
+	// under in a note, keyed by section
+	SectionAliases map[string][]string
+	// Position is one of the Position constants, PositionBeforeEnd when empty
+	Position Position
+	// CreateSectionIfMissing appends the section when the note doesn't have it
+	CreateSectionIfMissing bool
+	// Subheading is the sub-heading used by PositionUnderSubheading
+	Subheading string
+	// Marker is the marker line used by PositionAtMarker, DefaultMarker when empty
+	Marker string
+	// SectionOrder lists the note's sections in order. A missing section is created in
+	// its slot between the existing ones instead of at the end of the note.
+	SectionOrder []string
+	// ScaffoldSections creates all missing sections of SectionOrder
+	ScaffoldSections bool
+	// Template is the path of an Obsidian template new notes are created from.
+	// It is rendered with RenderNoteTemplate before the entry is inserted.
+	Template string
+	// Time is the entry's capture time, used by PositionSorted and new note
+	// templates. Inserter fills it from its clock when zero.
+	Time time.Time
+	// TimeZone is the zone the entries' timestamps are rendered in, which
+	// PositionSorted compares Time in. Time's own zone is used when nil.
+	TimeZone *time.Location
+	// Debug prints what the insertion does to stdout
+	Debug bool
+}
+
 // AddLine adds a line into a specific section of a markdown file
 func AddLine(projectDir, dailyNotePath, dailyNoteName, section, line, position string, createSectionIfMissing, debug bool) error {
 	if line == "" {
 		return nil
 	}
 
+	now := time.Now()
+	fullPath, err := ResolvePath(projectDir, dailyNotePath, dailyNoteName, now, debug)
+	if err != nil {
+		return err
+	}
+
+	return AddEntry(fullPath, line, Options{
+		Section:                section,
+		Position:               Position(position),
+		CreateSectionIfMissing: createSectionIfMissing,
+		Time:                   now,
+		Debug:                  debug,
+	})
+}
+
+// ResolvePath renders the date templates of a note's path against t, expands
+// environment variables and returns the full path of the note
+func ResolvePath(projectDir, notePath, noteName string, t time.Time, debug bool) (string, error) {
+	// Render date templates against the capture time
+	var err error
+	if notePath, err = RenderDateTemplate(notePath, t); err != nil {
+		return "", err
+	}
+	if noteName, err = RenderDateTemplate(noteName, t); err != nil {
+		return "", err
+	}
+
 	// Expand environment variables in paths
-	projectDir = expandPath(projectDir)
-	dailyNotePath = expandPath(dailyNotePath)
-	dailyNoteName = expandPath(dailyNoteName)
+	projectDir = ExpandPath(projectDir)
+	notePath = ExpandPath(notePath)
+	noteName = ExpandPath(noteName)
 
-	// Construct the full path to the daily note
-	fullPath := filepath.Join(projectDir, dailyNotePath, dailyNoteName)
+	// Construct the full path to the note
+	fullPath := filepath.Join(projectDir, notePath, noteName)
 
 	debugPrint(debug, "Debug: Expanded paths:\n")
 	debugPrint(debug, "  Project dir: %s\n", projectDir)
-	debugPrint(debug, "  Daily note path: %s\n", dailyNotePath)
-	debugPrint(debug, "  Daily note name: %s\n", dailyNoteName)
+	debugPrint(debug, "  Daily note path: %s\n", notePath)
+	debugPrint(debug, "  Daily note name: %s\n", noteName)
 	debugPrint(debug, "  Full path: %s\n", fullPath)
 
 	// Check if path still contains unexpanded environment variables
 	if strings.Contains(fullPath, "$") {
-		return fmt.Errorf("path contains unexpanded environment variables. Please use absolute paths or expand the variables:\n  Project dir: %s\n  Daily note path: %s\n  Daily note name: %s\n  Full path: %s",
-			projectDir, dailyNotePath, dailyNoteName, fullPath)
+		return "", fmt.Errorf("%w. Please use absolute paths or expand the variables:\n  Project dir: %s\n  Daily note path: %s\n  Daily note name: %s\n  Full path: %s",
+			ErrUnexpandedVariable, projectDir, notePath, noteName, fullPath)
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		debugPrint(debug, "Debug: File does not exist, creating it\n")
-		if err := createNewFile(fullPath, section, line, debug); err != nil {
-			return err
-		}
+	return fullPath, nil
+}
+
+// AddEntry adds a line to the note at fullPath as described by opts, creating the
+// note and the section as needed. A multi-line entry is inserted as a single block
+// in the note's line endings.
+func AddEntry(fullPath, line string, opts Options) error {
+	return NewInserter(WithOptions(opts)).Insert(context.Background(), fullPath, line)
+}
+
+// AddEntry adds an entry to the document under the section described by opts,
+// creating the section when it is missing and opts.CreateSectionIfMissing is set.
+// A multi-line entry is inserted as a single block in the document's line endings.
+func (d *Document) AddEntry(entry string, opts Options) error {
+	if entry == "" {
 		return nil
 	}
-
-	// Read file content
-	content, err := os.ReadFile(fullPath)
+	if err := checkPosition(opts); err != nil {
+		return err
+	}
+	matcher, err := newSectionMatcher(opts.Match, opts.Section, opts.SectionAliases)
 	if err != nil {
 		return err
 	}
+	lines := strings.Split(strings.ReplaceAll(entry, "\r\n", "\n"), "\n")
+
+	if opts.ScaffoldSections {
+		scaffoldSections(d, matcher, opts.SectionOrder, opts.Section)
+	}
 
 	// Check if section exists
-	if !strings.Contains(string(content), section) {
-		debugPrint(debug, "Debug: Section not found, creating it\n")
-		if createSectionIfMissing {
-			if err := appendSection(fullPath, section, line); err != nil {
-				return err
-			}
-			return nil
+	sec, found := matcher.find(d, opts.Section)
+	if !found {
+		if !opts.CreateSectionIfMissing {
+			return &SectionNotFoundError{Section: opts.Section}
 		}
-		return fmt.Errorf("section '%s' not found in file at %s and create_section_if_missing is false", section, fullPath)
+		debugPrint(opts.Debug, "Debug: Section not found, creating it\n")
+		createSection(d, matcher, lines, opts)
+		return nil
 	}
 
 	// Add line in the appropriate position
-	if err := addLineInSection(fullPath, section, line, position); err != nil {
-		return err
-	}
-
+	addLineInSection(d, sec, lines, opts)
 	return nil
 }
 
-// createNewFile creates a new markdown file with the given section and line
-func createNewFile(fullPath, section, line string, debug bool) error {
-	debugPrint(debug, "Debug: Creating directory structure for: %s\n", filepath.Dir(fullPath))
-	if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
-		return err
+// createSection creates the section with the given lines in its slot of the
+// section order, or at the end of the document. The configured section is used
+// as the heading, never one of its aliases.
+func createSection(doc *Document, m *sectionMatcher, lines []string, opts Options) {
+	at := sectionSlot(doc, m, opts.Section, opts.SectionOrder)
+	if at == len(doc.Lines) {
+		appendSection(doc, lines, opts)
+		return
 	}
 
-	content := fmt.Sprintf("%s\n%s\n", section, line)
-	debugPrint(debug, "Debug: Writing content to file: %s\n", fullPath)
-	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
-		return err
-	}
-
-	return nil
+	at = insertHeadingLines(doc, at)
+	insertSection(doc, at, lines, opts)
 }
 
-// appendSection appends a new section with the given line to the end of the file
-func appendSection(fullPath, section, line string) error {
-	content, err := os.ReadFile(fullPath)
-	if err != nil {
-		return err
+// insertHeadingLines makes room for a new section right before the heading at line
+// index at, keeping blank lines around it, and returns where the section goes
+func insertHeadingLines(doc *Document, at int) int {
+	doc.Insert(at, "")
+	if at > 0 && !isBlank(doc.Lines[at-1]) {
+		doc.Insert(at, "")
+		at++
 	}
+	return at
+}
 
-	// Add a newline if the file doesn't end with one
-	contentStr := string(content)
-	if len(contentStr) > 0 && !strings.HasSuffix(contentStr, "\n") {
-		contentStr += "\n"
+// sectionSlot returns the line index a missing section goes to: before the next
+// section of the order that exists in the document, after the previous one, or at
+// the end of the document
+func sectionSlot(doc *Document, m *sectionMatcher, section string, order []string) int {
+	idx := slices.IndexFunc(order, func(s string) bool { return sameHeading(s, section) })
+	if idx < 0 {
+		return len(doc.Lines)
 	}
-
-	// Add an extra newline if the file doesn't end with two newlines
-	if !strings.HasSuffix(contentStr, "\n\n") {
-		contentStr += "\n"
+	for _, next := range order[idx+1:] {
+		if sec, ok := m.find(doc, next); ok {
+			return sec.Start
+		}
 	}
-
-	// Append the new section and line
-	contentStr += fmt.Sprintf("%s\n%s\n", section, line)
-
-	if err := os.WriteFile(fullPath, []byte(contentStr), 0644); err != nil {
-		return err
+	for i := idx - 1; i >= 0; i-- {
+		if sec, ok := m.find(doc, order[i]); ok {
+			return sec.End
+		}
 	}
-
-	return nil
+	return len(doc.Lines)
 }
 
-// addLineInSection adds a line into an existing section
-func addLineInSection(fullPath, section, line, position string) error {
-	content, err := os.ReadFile(fullPath)
-	if err != nil {
-		return err
-	}
-
-	// Normalize line endings and split into lines
-	contentStr := strings.ReplaceAll(string(content), "\r\n", "\n")
-	lines := strings.Split(contentStr, "\n")
-
-	// Remove trailing empty lines from input
-	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
-		lines = lines[:len(lines)-1]
-	}
-
-	var newLines []string
-	sectionFound := false
-	endOfSection := false
-	lineAdded := false
-
-	for i, currentLine := range lines {
-		currentLine = strings.TrimRight(currentLine, "\r\n")
-		if strings.HasPrefix(currentLine, "## ") {
-			if sectionFound {
-				endOfSection = true
-				// Add a newline before the next section if needed
-				if len(newLines) > 0 && len(strings.TrimSpace(newLines[len(newLines)-1])) > 0 {
-					newLines = append(newLines, "")
-				}
-			}
-			if strings.TrimSpace(currentLine) == strings.TrimSpace(section) {
-				sectionFound = true
-				newLines = append(newLines, currentLine)
-				if position == "after-heading" {
-					newLines = append(newLines, line)
-					lineAdded = true
-				}
-				continue
-			}
+// scaffoldSections adds the sections of order missing from the document, empty,
+// in their slots. The target section is left to the insertion itself.
+func scaffoldSections(doc *Document, m *sectionMatcher, order []string, target string) {
+	for _, section := range order {
+		if sameHeading(section, target) {
+			continue
+		}
+		if _, ok := m.find(doc, section); ok {
+			continue
 		}
 
-		if sectionFound && !endOfSection && position == "before-end" && !lineAdded {
-			// Check if we're at the end of the section
-			isLastLine := i == len(lines)-1
-			nextLineIsSection := !isLastLine && strings.HasPrefix(lines[i+1], "## ")
-
-			if isLastLine || nextLineIsSection {
-				if len(strings.TrimSpace(currentLine)) > 0 {
-					newLines = append(newLines, currentLine)
-					newLines = append(newLines, line)
-				} else {
-					newLines = append(newLines, line)
-				}
-				lineAdded = true
-				continue
+		at := sectionSlot(doc, m, section, order)
+		if at == len(doc.Lines) {
+			if n := len(doc.Lines); n > 0 && !isBlank(doc.Lines[n-1]) {
+				doc.Insert(n, "")
 			}
+			doc.Insert(len(doc.Lines), sectionHeading(section))
+			continue
 		}
-
-		newLines = append(newLines, currentLine)
+		at = insertHeadingLines(doc, at)
+		doc.Insert(at, sectionHeading(section))
 	}
+}
 
-	if !lineAdded && sectionFound {
-		if len(newLines) > 0 && len(strings.TrimSpace(newLines[len(newLines)-1])) > 0 {
-			newLines = append(newLines, "")
-		}
-		newLines = append(newLines, line)
+// sectionHeading returns the heading a missing section is created with. A section
+// without heading marker becomes a level 2 heading, which it matches from then on.
+func sectionHeading(section string) string {
+	if level, text := ParseHeading(section); level == 0 {
+		return "## " + text
 	}
+	return section
+}
 
-	// Clean up multiple consecutive newlines and ensure proper spacing
-	var cleanLines []string
-	lastLineEmpty := false
-	lastLineWasSection := false
+// sameHeading reports whether two configured sections refer to the same heading
+func sameHeading(a, b string) bool {
+	levelA, textA := ParseHeading(a)
+	levelB, textB := ParseHeading(b)
+	return levelA == levelB && textA == textB
+}
 
-	for _, l := range newLines {
-		l = strings.TrimRight(l, "\r\n")
-		isSection := strings.HasPrefix(l, "## ")
+// appendSection appends a new section with the given lines to the end of the document,
+// separated from the existing content by a blank line
+func appendSection(doc *Document, lines []string, opts Options) {
+	if n := len(doc.Lines); n > 0 && !isBlank(doc.Lines[n-1]) {
+		doc.Insert(n, "")
+	}
+	insertSection(doc, len(doc.Lines), lines, opts)
+}
 
-		if l == "" {
-			if !lastLineEmpty && !lastLineWasSection {
-				cleanLines = append(cleanLines, l)
-			}
-			lastLineEmpty = true
-		} else {
-			if isSection && len(cleanLines) > 0 && !lastLineEmpty {
-				cleanLines = append(cleanLines, "")
-			}
-			cleanLines = append(cleanLines, l)
-			lastLineEmpty = false
+// insertSection inserts the section heading at line index at and adds lines to it
+func insertSection(doc *Document, at int, lines []string, opts Options) {
+	doc.Insert(at, sectionHeading(opts.Section))
+	for _, b := range doc.Blocks {
+		if b.Start == at && b.Kind == BlockHeading {
+			addLineInSection(doc, doc.sectionAt(b), lines, opts)
+			return
 		}
-		lastLineWasSection = isSection
 	}
 
-	// Remove trailing empty lines
-	for len(cleanLines) > 0 && cleanLines[len(cleanLines)-1] == "" {
-		cleanLines = cleanLines[:len(cleanLines)-1]
-	}
+	// The section is not a markdown heading, add the lines right below it
+	doc.Insert(at+1, lines...)
+}
 
-	// Add a newline at the end if the file doesn't end with a section
-	if len(cleanLines) > 0 {
-		lastLine := cleanLines[len(cleanLines)-1]
-		if strings.HasPrefix(lastLine, "- ") {
-			// If the last line is a note, don't add a newline
-		} else if !strings.HasPrefix(lastLine, "## ") {
-			// If the last line is not a section header or a note, add a newline
-			cleanLines = append(cleanLines, "")
+// addLineInSection adds the lines of an entry into an existing section of the
+// document. Only the added lines change: line endings, blank lines and the rest of
+// the file are kept as is.
+func addLineInSection(doc *Document, sec Section, lines []string, opts Options) {
+	at := insertionPoint(doc, sec, opts)
+
+	// Keep a paragraph that directly follows the entry from becoming a lazy
+	// continuation of the entry's list item. Markers stay right below the entries.
+	separate := false
+	if isListItem(lines[0]) && opts.Position != PositionAtMarker {
+		for _, b := range doc.Blocks {
+			if b.Start == at && b.Kind == BlockParagraph {
+				separate = true
+			}
 		}
 	}
 
-	// Join lines with a newline and ensure the file ends with exactly one newline
-	finalContent := strings.Join(cleanLines, "\n")
-	if !strings.HasSuffix(finalContent, "\n") {
-		finalContent += "\n"
+	// An entry line reading as a setext underline, such as an empty "- " item, would
+	// turn a paragraph right above it into a heading
+	if setextRe.MatchString(lines[0]) {
+		for _, b := range doc.Blocks {
+			if b.End == at && b.Kind == BlockParagraph {
+				doc.Insert(at, "")
+				at++
+				break
+			}
+		}
 	}
 
-	// Normalize line endings one last time
-	finalContent = strings.ReplaceAll(finalContent, "\r\n", "\n")
-	finalContent = strings.TrimRight(finalContent, "\r\n") + "\n"
+	doc.Insert(at, lines...)
+	if separate {
+		doc.Insert(at+len(lines), "")
+	}
+}
 
-	return os.WriteFile(fullPath, []byte(finalContent), 0644)
+// isListItem reports whether line starts a list item
+func isListItem(line string) bool {
+	return listItemRe.MatchString(line)
 }
@@ -3,7 +3,9 @@ package markdown
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAddLine(t *testing.T) {
@@ -283,3 +285,200 @@ func TestAddLineCreateSectionIfMissingAtEnd(t *testing.T) {
 		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, updatedContent)
 	}
 }
+
+func TestAddEntrySectionOrder(t *testing.T) {
+	order := []string{"## Tasks", "## Log", "## Fleeting Ideas", "## Review"}
+
+	tests := []struct {
+		name     string
+		content  string
+		section  string
+		scaffold bool
+		expected string
+	}{
+		{
+			name:     "before the next existing section",
+			content:  "# Day\n\n## Tasks\n- [ ] a\n\n## Review\n- done\n",
+			section:  "## Log",
+			expected: "# Day\n\n## Tasks\n- [ ] a\n\n## Log\n- New\n\n## Review\n- done\n",
+		},
+		{
+			name:     "after the previous existing section",
+			content:  "# Day\n\n## Tasks\n- [ ] a\n\n## Notes\n- unordered\n",
+			section:  "## Log",
+			expected: "# Day\n\n## Tasks\n- [ ] a\n\n## Log\n- New\n\n## Notes\n- unordered\n",
+		},
+		{
+			name:     "first section of the order",
+			content:  "# Day\n## Review\n- done\n",
+			section:  "## Tasks",
+			expected: "# Day\n\n## Tasks\n- New\n\n## Review\n- done\n",
+		},
+		{
+			name:     "section outside the order",
+			content:  "## Tasks\n- [ ] a\n\n## Review\n- done\n",
+			section:  "## Misc",
+			expected: "## Tasks\n- [ ] a\n\n## Review\n- done\n\n## Misc\n- New\n",
+		},
+		{
+			name:     "scaffold missing sections",
+			content:  "# Day\n\n## Log\n- a\n",
+			section:  "## Log",
+			scaffold: true,
+			expected: "# Day\n\n## Tasks\n\n## Log\n- a\n- New\n\n## Fleeting Ideas\n\n## Review\n",
+		},
+		{
+			name:     "scaffold new note",
+			section:  "## Fleeting Ideas",
+			scaffold: true,
+			expected: "## Tasks\n\n## Log\n\n## Fleeting Ideas\n- New\n\n## Review\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.md")
+			if tt.content != "" {
+				if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+					t.Fatalf("Failed to write test file: %v", err)
+				}
+			}
+
+			err := AddEntry(filePath, "- New", Options{
+				Section:                tt.section,
+				Position:               "before-end",
+				CreateSectionIfMissing: true,
+				SectionOrder:           order,
+				ScaffoldSections:       tt.scaffold,
+			})
+			if err != nil {
+				t.Fatalf("Failed to add entry: %v", err)
+			}
+
+			updated, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(updated) != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, updated)
+			}
+		})
+	}
+}
+
+func TestAddEntryCreateSectionWithoutMarker(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(filePath, []byte("# Day\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// The section is created as a heading, so the second entry finds it
+	for _, entry := range []string{"- a", "- b"} {
+		err := AddEntry(filePath, entry, Options{
+			Section:                "Fleeting Ideas",
+			Position:               "before-end",
+			CreateSectionIfMissing: true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to add entry: %v", err)
+		}
+	}
+
+	updated, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	expected := "# Day\n\n## Fleeting Ideas\n- a\n- b\n"
+	if string(updated) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, updated)
+	}
+}
+
+func TestAddEntryMultiline(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		position Position
+		expected string
+	}{
+		{
+			name:     "before end",
+			content:  "## Log\n- a\n\n## Other\n",
+			position: "before-end",
+			expected: "## Log\n- a\n- New\n  details\n  - nested\n\n## Other\n",
+		},
+		{
+			name:     "after heading keeps the next entry a separate item",
+			content:  "## Log\n- a\n",
+			position: "after-heading",
+			expected: "## Log\n- New\n  details\n  - nested\n- a\n",
+		},
+		{
+			name:     "crlf note",
+			content:  "## Log\r\n- a\r\n",
+			position: "before-end",
+			expected: "## Log\r\n- a\r\n- New\r\n  details\r\n  - nested\r\n",
+		},
+		{
+			name:     "followed by a paragraph",
+			content:  "## Log\nSome text.\n",
+			position: "after-heading",
+			expected: "## Log\n- New\n  details\n  - nested\n\nSome text.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, filePath := writeTestNote(t, tt.content)
+
+			if err := AddEntry(filePath, "- New\r\n  details\n  - nested", Options{Section: "## Log", Position: tt.position}); err != nil {
+				t.Fatalf("Failed to add entry: %v", err)
+			}
+
+			updated, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(updated) != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, updated)
+			}
+
+			// The next entry must still land after the whole multi-line entry
+			if tt.position == "before-end" {
+				if err := AddEntry(filePath, "- Next", Options{Section: "## Log", Position: tt.position}); err != nil {
+					t.Fatalf("Failed to add entry: %v", err)
+				}
+				updated, _ = os.ReadFile(filePath)
+				if !strings.Contains(strings.ReplaceAll(string(updated), "\r\n", "\n"), "  - nested\n- Next\n") {
+					t.Errorf("Expected the next entry after the multi-line entry, got:\n%q", updated)
+				}
+			}
+		})
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("MARKIN_TEST_VAULT", "/vault")
+	day := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		projectDir string
+		expected   string
+	}{
+		{"/vault", "/vault/daily/2026-10-17.md"},
+		{"$MARKIN_TEST_VAULT", "/vault/daily/2026-10-17.md"},
+		{"~/vault", "/home/me/vault/daily/2026-10-17.md"},
+		{"~", "/home/me/daily/2026-10-17.md"},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolvePath(tt.projectDir, "daily", "{{.Date}}.md", day, false)
+		if err != nil {
+			t.Fatalf("Failed to resolve path for %q: %v", tt.projectDir, err)
+		}
+		if got != tt.expected {
+			t.Errorf("ResolvePath(%q) = %q, expected %q", tt.projectDir, got, tt.expected)
+		}
+	}
+}
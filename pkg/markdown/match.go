@@ -0,0 +1,144 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Modes of matching a configured section against the headings of a note
+const (
+	// MatchExact matches headings with the configured level and text
+	MatchExact = "exact"
+	// MatchNormalized matches headings ignoring level, case, emoji and whitespace,
+	// so "## 💡 Fleeting  Ideas" matches "### fleeting ideas"
+	MatchNormalized = "normalized"
+	// MatchRegex matches the section exactly and treats its aliases as regular
+	// expressions matched against the heading, e.g. "^#{2,3} .*Ideas$". The section
+	// must have aliases, since the section itself is the heading new sections get.
+	MatchRegex = "regex"
+)
+
+// MatchModes lists the supported section matching modes
+var MatchModes = []string{MatchExact, MatchNormalized, MatchRegex}
+
+// ValidMatchMode reports whether mode is supported. An empty mode means MatchExact.
+func ValidMatchMode(mode string) bool {
+	return mode == "" || slices.Contains(MatchModes, mode)
+}
+
+// ValidateAliases checks that the aliases of a section can be used with mode,
+// i.e. that they are valid regular expressions in MatchRegex mode
+func ValidateAliases(mode string, aliases []string) error {
+	if !ValidMatchMode(mode) {
+		return fmt.Errorf("invalid section match mode %q, expected one of: %s", mode, strings.Join(MatchModes, ", "))
+	}
+	if mode != MatchRegex {
+		return nil
+	}
+	for _, alias := range aliases {
+		if _, err := regexp.Compile(alias); err != nil {
+			return fmt.Errorf("invalid section alias %q: %w", alias, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSectionMatch checks that section can be found in mode with its aliases.
+// MatchRegex mode needs aliases, as it would otherwise match the section exactly.
+func ValidateSectionMatch(mode, section string, aliases []string) error {
+	if mode == MatchRegex && len(aliases) == 0 {
+		return fmt.Errorf("section match mode %q needs aliases for section %q, which is matched exactly", mode, section)
+	}
+	return nil
+}
+
+// MatchSection returns the first section whose heading matches the configured
+// section, or else one of its aliases, in the given matching mode. The section
+// itself always wins over its aliases.
+func (d *Document) MatchSection(section string, aliases []string, mode string) (Section, bool, error) {
+	m, err := newSectionMatcher(mode, section, map[string][]string{section: aliases})
+	if err != nil {
+		return Section{}, false, err
+	}
+	sec, ok := m.find(d, section)
+	return sec, ok, nil
+}
+
+// sectionMatcher finds configured sections in documents
+type sectionMatcher struct {
+	mode    string
+	aliases map[string][]string
+	regexps map[string]*regexp.Regexp
+}
+
+// newSectionMatcher returns a matcher for the mode with the aliases of each
+// configured section, compiling regular expression aliases up front. In MatchRegex
+// mode, section is the section entries go to and must have aliases.
+func newSectionMatcher(mode, section string, aliases map[string][]string) (*sectionMatcher, error) {
+	if err := ValidateSectionMatch(mode, section, aliases[section]); err != nil {
+		return nil, err
+	}
+	m := &sectionMatcher{mode: mode, aliases: aliases, regexps: make(map[string]*regexp.Regexp)}
+	for _, list := range aliases {
+		if err := ValidateAliases(mode, list); err != nil {
+			return nil, err
+		}
+		if mode == MatchRegex {
+			for _, alias := range list {
+				m.regexps[alias] = regexp.MustCompile(alias)
+			}
+		}
+	}
+	return m, nil
+}
+
+// find returns the section matching section, or else one of its aliases
+func (m *sectionMatcher) find(d *Document, section string) (Section, bool) {
+	headings := d.Headings()
+	for _, h := range headings {
+		if m.matches(h, section, false) {
+			return d.sectionAt(h), true
+		}
+	}
+	for _, alias := range m.aliases[section] {
+		for _, h := range headings {
+			if m.matches(h, alias, true) {
+				return d.sectionAt(h), true
+			}
+		}
+	}
+	return Section{}, false
+}
+
+// matches reports whether heading h matches a configured section or alias
+func (m *sectionMatcher) matches(h Block, section string, alias bool) bool {
+	switch {
+	case m.mode == MatchRegex && alias:
+		return m.regexps[section].MatchString(strings.Repeat("#", h.Level) + " " + h.Text)
+	case m.mode == MatchNormalized:
+		_, text := ParseHeading(section)
+		if want := normalizeHeading(text); want != "" {
+			return normalizeHeading(h.Text) == want
+		}
+	}
+	// Exact match. A section without heading marker matches headings of any level.
+	level, text := ParseHeading(section)
+	return h.Text == text && (level == 0 || h.Level == level)
+}
+
+// normalizeHeading lowercases heading text and drops emoji, symbols and extra
+// whitespace
+func normalizeHeading(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Sk, r), unicode.Is(unicode.Cf, r),
+			unicode.Is(unicode.Me, r), unicode.Is(unicode.Variation_Selector, r):
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,138 @@
+package markdown
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMatchSection(t *testing.T) {
+	doc := Parse("# Day\n\n## 💡 🧠 🔥 Fleeting  Ideas\n- a\n\n### Notes ✍️\n- b\n\n## Scratchpad\n- c\n\nReview\n------\n")
+
+	tests := []struct {
+		name    string
+		section string
+		aliases []string
+		mode    string
+		heading string
+		found   bool
+	}{
+		{"exact", "## 💡 🧠 🔥 Fleeting  Ideas", nil, MatchExact, "💡 🧠 🔥 Fleeting  Ideas", true},
+		{"exact misses without emoji", "## Fleeting Ideas", nil, MatchExact, "", false},
+		{"exact misses other level", "## Notes ✍️", nil, "", "", false},
+		{"exact any level", "Review", nil, "", "Review", true},
+		{"normalized without emoji", "## Fleeting Ideas", nil, MatchNormalized, "💡 🧠 🔥 Fleeting  Ideas", true},
+		{"normalized other emoji and case", "## ✨ fleeting ideas ✨", nil, MatchNormalized, "💡 🧠 🔥 Fleeting  Ideas", true},
+		{"normalized other level", "## notes", nil, MatchNormalized, "Notes ✍️", true},
+		{"normalized setext", "# REVIEW", nil, MatchNormalized, "Review", true},
+		{"normalized emoji only section is exact", "## 🔥", nil, MatchNormalized, "", false},
+		{"exact alias", "## 💭 Notes", []string{"## Scratchpad"}, MatchExact, "Scratchpad", true},
+		{"normalized alias", "## 💭 Thoughts", []string{"## 📝 scratchpad"}, MatchNormalized, "Scratchpad", true},
+		{"section wins over alias", "## Scratchpad", []string{"### Notes ✍️"}, MatchExact, "Scratchpad", true},
+		{"regex alias", "## 💭 Notes", []string{`^#{2,3} Notes`}, MatchRegex, "Notes ✍️", true},
+		{"regex alias with level", "## Ideas", []string{`^## .*Ideas$`}, MatchRegex, "💡 🧠 🔥 Fleeting  Ideas", true},
+		{"regex alias misses level", "## Ideas", []string{`^# .*Ideas$`}, MatchRegex, "", false},
+		{"regex section is literal", "## Scratch.*", []string{`^# Nope$`}, MatchRegex, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sec, found, err := doc.MatchSection(tt.section, tt.aliases, tt.mode)
+			if err != nil {
+				t.Fatalf("Failed to match section: %v", err)
+			}
+			if found != tt.found {
+				t.Fatalf("Expected found %v, got %v", tt.found, found)
+			}
+			if found && sec.Heading.Text != tt.heading {
+				t.Errorf("Expected heading %q, got %q", tt.heading, sec.Heading.Text)
+			}
+		})
+	}
+}
+
+func TestMatchSectionInvalid(t *testing.T) {
+	doc := Parse("## Log\n")
+	if _, _, err := doc.MatchSection("## Log", []string{"(unclosed"}, MatchRegex); err == nil {
+		t.Error("Expected error for invalid regex alias")
+	}
+	// Regex mode would otherwise match the section exactly
+	if _, _, err := doc.MatchSection("## Log", nil, MatchRegex); err == nil {
+		t.Error("Expected error for regex mode without aliases")
+	}
+	if _, _, err := doc.MatchSection("## Log", nil, "fuzzy"); err == nil {
+		t.Error("Expected error for invalid match mode")
+	}
+	// Aliases are plain headings outside regex mode
+	if _, _, err := doc.MatchSection("## Log", []string{"(unclosed"}, MatchNormalized); err != nil {
+		t.Errorf("Expected no error for a non-regex alias, got %v", err)
+	}
+}
+
+func TestAddEntrySectionMatching(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "normalized finds the section instead of appending a duplicate",
+			content:  "## 💡 🧠 🔥 Fleeting Ideas\n- a\n",
+			opts:     Options{Section: "## Fleeting Ideas", Match: MatchNormalized},
+			expected: "## 💡 🧠 🔥 Fleeting Ideas\n- a\n- New\n",
+		},
+		{
+			name:     "alias",
+			content:  "## Ideas\n- a\n",
+			opts:     Options{Section: "## 💡 Fleeting Ideas", SectionAliases: map[string][]string{"## 💡 Fleeting Ideas": {"## Ideas"}}},
+			expected: "## Ideas\n- a\n- New\n",
+		},
+		{
+			name:     "canonical heading used when creating",
+			content:  "## Log\n- a\n",
+			opts:     Options{Section: "## 💡 Fleeting Ideas", Match: MatchNormalized, SectionAliases: map[string][]string{"## 💡 Fleeting Ideas": {"## Ideas"}}},
+			expected: "## Log\n- a\n\n## 💡 Fleeting Ideas\n- New\n",
+		},
+		{
+			name:    "section order slots use aliases",
+			content: "## todo\n- t\n\n## Review\n",
+			opts: Options{
+				Section:        "## 📝 Log",
+				Match:          MatchNormalized,
+				SectionOrder:   []string{"## ✅ Tasks", "## 📝 Log", "## 🔎 Review"},
+				SectionAliases: map[string][]string{"## ✅ Tasks": {"## Todo"}},
+			},
+			expected: "## todo\n- t\n\n## 📝 Log\n- New\n\n## Review\n",
+		},
+		{
+			name:    "scaffolding skips sections found by alias",
+			content: "## Todo\n- t\n",
+			opts: Options{
+				Section:          "## 📝 Log",
+				SectionOrder:     []string{"## ✅ Tasks", "## 📝 Log"},
+				ScaffoldSections: true,
+				SectionAliases:   map[string][]string{"## ✅ Tasks": {"## Todo"}},
+			},
+			expected: "## Todo\n- t\n\n## 📝 Log\n- New\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, filePath := writeTestNote(t, tt.content)
+
+			tt.opts.CreateSectionIfMissing = true
+			if err := AddEntry(filePath, "- New", tt.opts); err != nil {
+				t.Fatalf("Failed to add entry: %v", err)
+			}
+
+			updated, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(updated) != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, updated)
+			}
+		})
+	}
+}
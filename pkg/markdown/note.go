@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoteNotFound is returned when no note of the vault has the requested title or alias
+var ErrNoteNotFound = errors.New("note not found")
+
+// ResolveNote returns the full path of the note a capture targets. note is either a
+// path relative to projectDir, recognized by a path separator or the .md extension,
+// or a title looked up case-insensitively among the names and frontmatter aliases
+// of the vault's notes in fsys. [[wikilink]] brackets and a |display text are ignored.
+func ResolveNote(fsys afero.Fs, projectDir, note string, debug bool) (string, error) {
+	projectDir = ExpandPath(projectDir)
+	note = noteName(note)
+	if note == "" {
+		return "", fmt.Errorf("note name is empty")
+	}
+
+	if IsNotePath(note) {
+		path := ExpandPath(note)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		if filepath.Ext(path) == "" {
+			path += ".md"
+		}
+		debugPrint(debug, "Debug: Note path: %s\n", path)
+		return path, nil
+	}
+
+	path, err := findNote(fsys, projectDir, note)
+	if err != nil {
+		return "", err
+	}
+	debugPrint(debug, "Debug: Note %q resolved to %s\n", note, path)
+	return path, nil
+}
+
+// IsNotePath reports whether ResolveNote takes note as a path rather than a title
+func IsNotePath(note string) bool {
+	note = noteName(note)
+	return strings.ContainsAny(note, `/\`) || strings.EqualFold(filepath.Ext(note), ".md")
+}
+
+// noteName strips note of surrounding spaces, [[wikilink]] brackets and a |display text
+func noteName(note string) string {
+	note = strings.TrimSpace(note)
+	note = strings.TrimSuffix(strings.TrimPrefix(note, "[["), "]]")
+	if i := strings.IndexByte(note, '|'); i >= 0 {
+		note = note[:i]
+	}
+	return note
+}
+
+// findNote searches the vault for the note titled title, preferring file names
+// over aliases
+func findNote(fsys afero.Fs, vaultDir, title string) (string, error) {
+	var byName, byAlias []string
+	err := afero.Walk(fsys, vaultDir, func(path string, d os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip .obsidian, .trash, .git and the like
+			if path != vaultDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		name := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		if strings.EqualFold(name, title) {
+			byName = append(byName, path)
+			return nil
+		}
+		if len(byName) == 0 {
+			aliases, err := noteAliases(fsys, path)
+			if err != nil {
+				return err
+			}
+			for _, alias := range aliases {
+				if strings.EqualFold(alias, title) {
+					byAlias = append(byAlias, path)
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search %s: %w", vaultDir, err)
+	}
+
+	matches := byName
+	if len(matches) == 0 {
+		matches = byAlias
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: no note named %q in %s", ErrNoteNotFound, title, vaultDir)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("note %q is ambiguous, matching: %s", title, strings.Join(matches, ", "))
+	}
+}
+
+// noteAliases returns the Obsidian aliases declared in the frontmatter of the note
+// at path, either as a list or a single value under aliases or alias
+func noteAliases(fsys afero.Fs, path string) ([]string, error) {
+	content, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(string(content), "---") {
+		return nil, nil
+	}
+	lines, ok := Parse(string(content)).Frontmatter()
+	if !ok {
+		return nil, nil
+	}
+
+	var fm map[string]any
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &fm); err != nil {
+		// Notes with broken frontmatter simply have no aliases
+		return nil, nil
+	}
+
+	var aliases []string
+	for _, key := range []string{"aliases", "alias"} {
+		switch v := fm[key].(type) {
+		case string:
+			aliases = append(aliases, v)
+		case []any:
+			for _, a := range v {
+				if s, ok := a.(string); ok {
+					aliases = append(aliases, s)
+				}
+			}
+		}
+	}
+	return aliases, nil
+}
@@ -0,0 +1,99 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestResolveNote(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := "/vault"
+	notes := map[string]string{
+		"Projects/Markin.md":        "## Log\n",
+		"Projects/Garden Plan.md":   "---\naliases:\n  - Allotment\n  - veggies\n---\n## Log\n",
+		"Areas/Health.md":           "---\nalias: Fitness\n---\n",
+		"Areas/Reading List.md":     "---\naliases: [Books]\n---\n",
+		"Archive/Old/Duplicate.md":  "",
+		"Archive/New/duplicate.md":  "",
+		"Broken.md":                 "---\naliases: [unclosed\n---\n",
+		".trash/Deleted.md":         "",
+		"Projects/Markin notes.txt": "",
+	}
+	for name, content := range notes {
+		if err := afero.WriteFile(fsys, filepath.Join(vault, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write note: %v", err)
+		}
+	}
+
+	tests := []struct {
+		note     string
+		expected string
+	}{
+		{"Markin", "Projects/Markin.md"},
+		{"markin", "Projects/Markin.md"},
+		{"[[Garden Plan]]", "Projects/Garden Plan.md"},
+		{"[[Garden Plan|the garden]]", "Projects/Garden Plan.md"},
+		{"allotment", "Projects/Garden Plan.md"},
+		{"Veggies", "Projects/Garden Plan.md"},
+		{"fitness", "Areas/Health.md"},
+		{"Books", "Areas/Reading List.md"},
+		{"Projects/Markin", "Projects/Markin.md"},
+		{"Projects/New Project.md", "Projects/New Project.md"},
+		{"Inbox.md", "Inbox.md"},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveNote(fsys, vault, tt.note, false)
+		if err != nil {
+			t.Errorf("ResolveNote(%q) failed: %v", tt.note, err)
+			continue
+		}
+		if expected := filepath.Join(vault, tt.expected); got != expected {
+			t.Errorf("ResolveNote(%q) = %q, expected %q", tt.note, got, expected)
+		}
+	}
+
+	if _, err := ResolveNote(fsys, vault, "Nowhere", false); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound for a missing note, got %v", err)
+	}
+	if _, err := ResolveNote(fsys, vault, "Deleted", false); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected notes in hidden directories to be skipped, got %v", err)
+	}
+	if _, err := ResolveNote(fsys, vault, "duplicate", false); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected ambiguous note error, got %v", err)
+	}
+	if _, err := ResolveNote(fsys, vault, "[[]]", false); err == nil {
+		t.Error("Expected error for an empty note name")
+	}
+}
+
+func TestAddEntryToNote(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	vault := "/vault"
+	path := filepath.Join(vault, "Projects", "Markin.md")
+	if err := afero.WriteFile(fsys, path, []byte("---\naliases: [markin-cli]\n---\n# Markin\n\n## Log\n- a\n"), 0644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	fullPath, err := ResolveNote(fsys, vault, "MARKIN-CLI", false)
+	if err != nil {
+		t.Fatalf("Failed to resolve note: %v", err)
+	}
+	in := NewInserter(WithStore(FSStore{Fs: fsys}), WithSection("## Log"))
+	if err := in.Insert(context.Background(), fullPath, "- b"); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+
+	content, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if expected := "---\naliases: [markin-cli]\n---\n# Markin\n\n## Log\n- a\n- b\n"; string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+}
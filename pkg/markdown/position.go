@@ -0,0 +1,261 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Position is where an entry is inserted within its section
+type Position string
+
+// Positions an entry can be inserted at within its section
+const (
+	// PositionAfterHeading inserts right below the section heading
+	PositionAfterHeading Position = "after-heading"
+	// PositionBeforeEnd inserts after the last non-blank line of the section
+	PositionBeforeEnd Position = "before-end"
+	// PositionAfterLastListItem inserts after the last list in the section, ahead of
+	// any trailing paragraph or table
+	PositionAfterLastListItem Position = "after-last-list-item"
+	// PositionSorted inserts among the section's list items in chronological order
+	// of their timestamps
+	PositionSorted Position = "sorted"
+	// PositionUnderSubheading inserts at the end of a sub-heading within the section,
+	// creating the sub-heading when needed
+	PositionUnderSubheading Position = "under-subheading"
+	// PositionAtMarker inserts right before a marker line such as <!-- markin:insert -->
+	// within the section, so entries accumulate above it in order
+	PositionAtMarker Position = "at-marker"
+)
+
+// DefaultMarker is the marker line used by PositionAtMarker when none is configured
+const DefaultMarker = "<!-- markin:insert -->"
+
+// Positions lists the supported positions
+var Positions = []Position{
+	PositionAfterHeading,
+	PositionBeforeEnd,
+	PositionAfterLastListItem,
+	PositionSorted,
+	PositionUnderSubheading,
+	PositionAtMarker,
+}
+
+// ValidPosition reports whether position is supported. An empty position means
+// PositionBeforeEnd.
+func ValidPosition(position Position) bool {
+	return position == "" || slices.Contains(Positions, position)
+}
+
+// checkPosition returns an error wrapping ErrInvalidPosition when the position of
+// opts is not supported or lacks its sub-heading
+func checkPosition(opts Options) error {
+	if !ValidPosition(opts.Position) {
+		return fmt.Errorf("%w %q, expected one of: %s", ErrInvalidPosition, opts.Position, positionNames())
+	}
+	if opts.Position == PositionUnderSubheading && strings.TrimSpace(opts.Subheading) == "" {
+		return fmt.Errorf("%w %q without a subheading", ErrInvalidPosition, opts.Position)
+	}
+	return nil
+}
+
+// positionNames returns the supported positions as a comma-separated list
+func positionNames() string {
+	names := make([]string, len(Positions))
+	for i, p := range Positions {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// insertionPoint returns the line index the entry goes to within sec. It may add
+// lines to the document, such as a missing sub-heading.
+func insertionPoint(doc *Document, sec Section, opts Options) int {
+	switch opts.Position {
+	case PositionAfterHeading:
+		return afterHeading(doc, sec)
+	case PositionAfterLastListItem:
+		if list, ok := lastList(doc, sec); ok {
+			return list.End - trailingBlankLines(doc, list)
+		}
+	case PositionSorted:
+		t := opts.Time
+		if opts.TimeZone != nil {
+			t = t.In(opts.TimeZone)
+		}
+		if at, ok := sortedPoint(doc, sec, t); ok {
+			return at
+		}
+	case PositionUnderSubheading:
+		return subheadingPoint(doc, sec, opts.Subheading)
+	case PositionAtMarker:
+		if at, ok := markerLine(doc, sec, opts.Marker); ok {
+			return at
+		}
+	}
+	return doc.ContentEnd(sec)
+}
+
+// afterHeading returns where after-heading entries go: right below the heading, or
+// below the blank lines separating the heading from the section's content
+func afterHeading(doc *Document, sec Section) int {
+	at := sec.Body
+	for at < sec.End && isBlank(doc.Lines[at]) {
+		at++
+	}
+	if at == sec.End {
+		return sec.Body
+	}
+	for _, b := range doc.Blocks {
+		if b.Start == at && b.Kind == BlockHeading {
+			return sec.Body
+		}
+	}
+	return at
+}
+
+// lastList returns the last list block within the section
+func lastList(doc *Document, sec Section) (Block, bool) {
+	var list Block
+	found := false
+	for _, b := range doc.BlocksIn(sec.Body, sec.End) {
+		if b.Kind == BlockList {
+			list, found = b, true
+		}
+	}
+	return list, found
+}
+
+// trailingBlankLines returns the number of blank lines at the end of a block
+func trailingBlankLines(doc *Document, b Block) int {
+	n := 0
+	for i := b.End - 1; i > b.Start && isBlank(doc.Lines[i]); i-- {
+		n++
+	}
+	return n
+}
+
+// clockRe matches times of day such as 09:12, 9:12:03 am or 21:05:00
+var clockRe = regexp.MustCompile(`(?i)(?:^|\D)(\d{1,2}):(\d{2})(?::(\d{2}))?(?:\s*([ap])\.?m\b)?`)
+
+// timeOfDay extracts the first time of day from text as seconds since midnight
+func timeOfDay(text string) (int, bool) {
+	m := clockRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second, _ := strconv.Atoi(m[3])
+	switch strings.ToLower(m[4]) {
+	case "a":
+		if hour == 12 {
+			hour = 0
+		}
+	case "p":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return 0, false
+	}
+	return hour*3600 + minute*60 + second, true
+}
+
+// sortedPoint returns the position keeping the section's timestamped list items in
+// chronological order: before the first item later than t, or after the last item
+func sortedPoint(doc *Document, sec Section, t time.Time) (int, bool) {
+	entry := t.Hour()*3600 + t.Minute()*60 + t.Second()
+
+	items := topLevelItems(doc, sec)
+	if len(items) == 0 {
+		return 0, false
+	}
+	for _, item := range items {
+		if ts, ok := timeOfDay(doc.Lines[item.Start]); ok && ts > entry {
+			return item.Start, true
+		}
+	}
+	last := items[len(items)-1]
+	return last.End, true
+}
+
+// topLevelItems returns the line ranges of the top-level items of the section's
+// lists, nested items and continuation lines included
+func topLevelItems(doc *Document, sec Section) []Block {
+	var items []Block
+	for _, list := range doc.BlocksIn(sec.Body, sec.End) {
+		if list.Kind != BlockList {
+			continue
+		}
+		indent := indentation(doc.Lines[list.Start])
+		end := list.End - trailingBlankLines(doc, list)
+		for i := list.Start; i < end; i++ {
+			if isListItem(doc.Lines[i]) && indentation(doc.Lines[i]) <= indent {
+				if n := len(items); n > 0 && items[n-1].End > i {
+					items[n-1].End = i
+				}
+				items = append(items, Block{Kind: BlockList, Start: i, End: end})
+			}
+		}
+	}
+	return items
+}
+
+// subheadingPoint returns the end of the named sub-heading within the section,
+// appending the sub-heading to the section first when it doesn't exist
+func subheadingPoint(doc *Document, sec Section, subheading string) int {
+	level, text := ParseHeading(subheading)
+	if level == 0 {
+		level = min(sec.Heading.Level+1, 6)
+		subheading = strings.Repeat("#", level) + " " + text
+	}
+
+	for _, h := range doc.BlocksIn(sec.Body, sec.End) {
+		if h.Kind == BlockHeading && h.Level == level && h.Text == text {
+			return doc.ContentEnd(doc.sectionAt(h))
+		}
+	}
+
+	at := doc.ContentEnd(sec)
+	if at > sec.Body {
+		doc.Insert(at, "", subheading)
+		return at + 2
+	}
+	doc.Insert(at, subheading)
+	return at + 1
+}
+
+// markerLine returns the line of the marker within the section
+func markerLine(doc *Document, sec Section, marker string) (int, bool) {
+	if marker == "" {
+		marker = DefaultMarker
+	}
+	for i := sec.Body; i < sec.End; i++ {
+		if strings.TrimSpace(doc.Lines[i]) == marker {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// indentation returns the width of the leading whitespace of line, tabs counting as 4
+func indentation(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
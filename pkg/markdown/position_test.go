@@ -0,0 +1,178 @@
+package markdown
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAddEntryPositions(t *testing.T) {
+	entryTime := time.Date(2026, time.October, 17, 13, 30, 0, 0, time.UTC)
+	// Noon UTC captured on a machine in Los Angeles
+	pacific := time.FixedZone("PDT", -7*60*60)
+	noonUTC := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC).In(pacific)
+
+	tests := []struct {
+		name     string
+		content  string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "after last list item skips trailing paragraph",
+			content:  "## Log\n- a\n- b\n\nSummary paragraph.\n\n## Other\n",
+			opts:     Options{Position: PositionAfterLastListItem},
+			expected: "## Log\n- a\n- b\n- New\n\nSummary paragraph.\n\n## Other\n",
+		},
+		{
+			name:     "after last list item skips trailing table",
+			content:  "## Log\n- a\n\n| x | y |\n| - | - |\n",
+			opts:     Options{Position: PositionAfterLastListItem},
+			expected: "## Log\n- a\n- New\n\n| x | y |\n| - | - |\n",
+		},
+		{
+			name:     "after last list item keeps nested items with their parent",
+			content:  "## Log\n- a\n  - a.1\n",
+			opts:     Options{Position: PositionAfterLastListItem},
+			expected: "## Log\n- a\n  - a.1\n- New\n",
+		},
+		{
+			name:     "after last list item without list",
+			content:  "## Log\nJust text.\n",
+			opts:     Options{Position: PositionAfterLastListItem},
+			expected: "## Log\nJust text.\n- New\n",
+		},
+		{
+			name:     "sorted in the middle",
+			content:  "## Log\n- *09:00:00 am:* first\n- *03:00:00 pm:* last\n",
+			opts:     Options{Position: PositionSorted, Time: entryTime},
+			expected: "## Log\n- *09:00:00 am:* first\n- New\n- *03:00:00 pm:* last\n",
+		},
+		{
+			name:     "sorted with 24h and iso timestamps",
+			content:  "## Log\n- 08:15 standup\n  - notes\n- 2026-10-17T14:00:00+02:00 review\n",
+			opts:     Options{Position: PositionSorted, Time: entryTime},
+			expected: "## Log\n- 08:15 standup\n  - notes\n- New\n- 2026-10-17T14:00:00+02:00 review\n",
+		},
+		{
+			name:     "sorted at the end",
+			content:  "## Log\n- 08:15 standup\n- untimed\n\n## Other\n",
+			opts:     Options{Position: PositionSorted, Time: entryTime},
+			expected: "## Log\n- 08:15 standup\n- untimed\n- New\n\n## Other\n",
+		},
+		{
+			name:     "sorted in the time zone of the timestamps",
+			content:  "## Log\n- 09:00 a\n- 13:00 b\n",
+			opts:     Options{Position: PositionSorted, Time: noonUTC, TimeZone: time.UTC},
+			expected: "## Log\n- 09:00 a\n- New\n- 13:00 b\n",
+		},
+		{
+			name:     "sorted in the zone of the capture time",
+			content:  "## Log\n- 04:00 a\n- 09:00 b\n",
+			opts:     Options{Position: PositionSorted, Time: noonUTC},
+			expected: "## Log\n- 04:00 a\n- New\n- 09:00 b\n",
+		},
+		{
+			name:     "under existing sub-heading",
+			content:  "## Log\n### Morning\n- coffee\n\n### Afternoon\n- lunch\n\n## Other\n",
+			opts:     Options{Position: PositionUnderSubheading, Subheading: "Morning"},
+			expected: "## Log\n### Morning\n- coffee\n- New\n\n### Afternoon\n- lunch\n\n## Other\n",
+		},
+		{
+			name:     "under missing sub-heading",
+			content:  "## Log\n- a\n\n## Other\n",
+			opts:     Options{Position: PositionUnderSubheading, Subheading: "### Evening"},
+			expected: "## Log\n- a\n\n### Evening\n- New\n\n## Other\n",
+		},
+		{
+			name:     "at marker",
+			content:  "## Log\n- a\n<!-- markin:insert -->\n- pinned\n",
+			opts:     Options{Position: PositionAtMarker},
+			expected: "## Log\n- a\n- New\n<!-- markin:insert -->\n- pinned\n",
+		},
+		{
+			name:     "at custom marker",
+			content:  "## Log\n%% here %%\n",
+			opts:     Options{Position: PositionAtMarker, Marker: "%% here %%"},
+			expected: "## Log\n- New\n%% here %%\n",
+		},
+		{
+			name:     "at missing marker",
+			content:  "## Log\n- a\n",
+			opts:     Options{Position: PositionAtMarker},
+			expected: "## Log\n- a\n- New\n",
+		},
+		{
+			name:     "at marker of another section",
+			content:  "## Log\n- a\n\n## Other\n<!-- markin:insert -->\n",
+			opts:     Options{Position: PositionAtMarker},
+			expected: "## Log\n- a\n- New\n\n## Other\n<!-- markin:insert -->\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, filePath := writeTestNote(t, tt.content)
+
+			tt.opts.Section = "## Log"
+			if err := AddEntry(filePath, "- New", tt.opts); err != nil {
+				t.Fatalf("Failed to add entry: %v", err)
+			}
+
+			updated, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			if string(updated) != tt.expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, updated)
+			}
+		})
+	}
+}
+
+func TestAddEntryUnderSubheadingNewFile(t *testing.T) {
+	filePath := t.TempDir() + "/new.md"
+
+	err := AddEntry(filePath, "- New", Options{Section: "## Log", Position: PositionUnderSubheading, Subheading: "Morning"})
+	if err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if expected := "## Log\n### Morning\n- New\n"; string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+}
+
+func TestAddEntryInvalidPosition(t *testing.T) {
+	_, filePath := writeTestNote(t, "## Log\n")
+
+	if err := AddEntry(filePath, "- New", Options{Section: "## Log", Position: "middle"}); err == nil {
+		t.Error("Expected error for invalid position")
+	}
+}
+
+func TestTimeOfDay(t *testing.T) {
+	tests := []struct {
+		text    string
+		seconds int
+		ok      bool
+	}{
+		{"- ⚡ *06:33:45 pm:* **Fleeting**:: note", 18*3600 + 33*60 + 45, true},
+		{"- 12:05 am late", 5 * 60, true},
+		{"- 12:05 PM lunch", 12*3600 + 5*60, true},
+		{"- 2026-10-17T09:30:00Z", 9*3600 + 30*60, true},
+		{"- no time here", 0, false},
+		{"- ratio 99:99", 0, false},
+	}
+
+	for _, tt := range tests {
+		seconds, ok := timeOfDay(tt.text)
+		if ok != tt.ok || seconds != tt.seconds {
+			t.Errorf("timeOfDay(%q) = %d, %v, expected %d, %v", tt.text, seconds, ok, tt.seconds, tt.ok)
+		}
+	}
+}
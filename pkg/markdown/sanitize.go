@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Sanitization levels of entry text
+const (
+	// SanitizeNone inserts the text as is
+	SanitizeNone = "none"
+	// SanitizeStructural escapes lines that would change the structure of the note:
+	// headings, thematic breaks and setext underlines (including frontmatter
+	// delimiters), code fences, math blocks, HTML blocks and Obsidian comments
+	SanitizeStructural = "structural"
+	// SanitizeStrict also escapes blockquotes, callouts, tables and list markers, so
+	// the text can only ever be plain paragraphs under the entry
+	SanitizeStrict = "strict"
+)
+
+// SanitizeLevels lists the supported sanitization levels
+var SanitizeLevels = []string{SanitizeNone, SanitizeStructural, SanitizeStrict}
+
+// ValidSanitizeLevel reports whether level is supported. An empty level means
+// SanitizeStructural.
+func ValidSanitizeLevel(level string) bool {
+	return level == "" || slices.Contains(SanitizeLevels, level)
+}
+
+var (
+	// structuralRe matches the starts of lines that open a block able to swallow or
+	// split the rest of the note
+	structuralRe = regexp.MustCompile("^(?:#{1,6}(?:[ \t]|$)|```|~~~|\\$\\$|<|%%)")
+	// ruleRe matches thematic breaks and setext heading underlines, e.g. "---",
+	// "- - -", "===" or "***"
+	ruleRe = regexp.MustCompile(`^(?:(?:-[ \t]*)+|(?:=[ \t]*)+|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	// strictRe matches the starts of blockquotes, callouts, tables and bullet items
+	strictRe = regexp.MustCompile(`^(?:>|\||[-*+](?:[ \t]|$))`)
+	// orderedRe matches the start of an ordered list item, e.g. "1." or "2)"
+	orderedRe = regexp.MustCompile(`^(\d{1,9})([.)])(?:[ \t]|$)`)
+)
+
+// Sanitize escapes the markdown in entry text that would break the structure of
+// the note it is inserted into. Escaped lines render the same in Obsidian, with
+// the markdown characters shown literally. Line breaks are normalized to \n and
+// control characters other than tabs are dropped.
+func Sanitize(text, level string) string {
+	if level == SanitizeNone {
+		return text
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || (r >= 0x20 && r != 0x7f) {
+			return r
+		}
+		return -1
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = sanitizeLine(line, level)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sanitizeLine escapes the first markdown character of a line that would start a
+// block. Indentation is kept: it changes once the line is nested under the entry.
+func sanitizeLine(line, level string) string {
+	content := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(content)]
+
+	switch {
+	case structuralRe.MatchString(content), ruleRe.MatchString(content):
+		return indent + `\` + content
+	case level != SanitizeStrict:
+		return line
+	case strictRe.MatchString(content):
+		return indent + `\` + content
+	}
+	if m := orderedRe.FindStringSubmatchIndex(content); m != nil {
+		// Escape the delimiter: "1. item" becomes "1\. item"
+		return indent + content[:m[3]] + `\` + content[m[3]:]
+	}
+	return line
+}
@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlisia/markin/internal/entry"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		level    string
+		expected string
+	}{
+		{"plain text", "just a note", SanitizeStructural, "just a note"},
+		{"heading", "## New section", SanitizeStructural, `\## New section`},
+		{"bare hash", "#", SanitizeStructural, `\#`},
+		{"tag is kept", "#idea worth keeping", SanitizeStructural, "#idea worth keeping"},
+		{"indented heading", "first\n   # heading", SanitizeStructural, "first\n   \\# heading"},
+		{"frontmatter delimiter", "---\ntitle: x\n---", SanitizeStructural, "\\---\ntitle: x\n\\---"},
+		{"setext underline", "Title\n===", SanitizeStructural, "Title\n\\==="},
+		{"setext dash", "Title\n-", SanitizeStructural, "Title\n\\-"},
+		{"thematic break", "a\n* * *\n___", SanitizeStructural, "a\n\\* * *\n\\___"},
+		{"code fence", "```go\nfmt.Println()", SanitizeStructural, "\\```go\nfmt.Println()"},
+		{"tilde fence", "~~~", SanitizeStructural, `\~~~`},
+		{"math block", "$$\nx^2", SanitizeStructural, "\\$$\nx^2"},
+		{"html block", "<div>\n<!-- open", SanitizeStructural, "\\<div>\n\\<!-- open"},
+		{"obsidian comment", "%% hidden", SanitizeStructural, `\%% hidden`},
+		{"already escaped", `\## kept`, SanitizeStructural, `\## kept`},
+		{"list items are kept", "todo\n- one\n1. two", SanitizeStructural, "todo\n- one\n1. two"},
+		{"quote is kept", "> quoted", SanitizeStructural, "> quoted"},
+		{"line endings", "a\r\nb\rc", SanitizeStructural, "a\nb\nc"},
+		{"control characters", "a\x00b\x1b[31mc\x7f\td", SanitizeStructural, "ab[31mc\td"},
+		{"strict quote", "> quoted\n> [!note]", SanitizeStrict, "\\> quoted\n\\> [!note]"},
+		{"strict table", "| a | b |", SanitizeStrict, `\| a | b |`},
+		{"strict bullets", "- one\n  * two\n+", SanitizeStrict, "\\- one\n  \\* two\n\\+"},
+		{"strict ordered", "1. one\n2) two\n2026 was good", SanitizeStrict, "1\\. one\n2\\) two\n2026 was good"},
+		{"strict heading", "# h", SanitizeStrict, `\# h`},
+		{"none", "## h\r\n---", SanitizeNone, "## h\r\n---"},
+		{"default level", "## h", "", `\## h`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.text, tt.level); got != tt.expected {
+				t.Errorf("Sanitize(%q, %q) = %q, expected %q", tt.text, tt.level, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidSanitizeLevel(t *testing.T) {
+	for _, level := range []string{"", SanitizeNone, SanitizeStructural, SanitizeStrict} {
+		if !ValidSanitizeLevel(level) {
+			t.Errorf("Expected %q to be valid", level)
+		}
+	}
+	if ValidSanitizeLevel("paranoid") {
+		t.Error("Expected unknown level to be invalid")
+	}
+}
+
+// fuzzNote is the note the fuzz test inserts entries into
+const fuzzNote = "---\ntitle: Day\n---\n# Day\n\n## Log\n- a\n\nParagraph.\n\n## Other\n- b\n"
+
+func FuzzSanitizedInsertion(f *testing.F) {
+	for _, seed := range []string{
+		"plain note",
+		"## Heading",
+		"line\n---\ntitle: x\n---",
+		"```go\nfmt.Println()",
+		"first\n# h1\n## h2",
+		"text\n===",
+		"text\n-",
+		"<div>\nunclosed",
+		"<!-- open comment",
+		"%% hidden",
+		"$$\nx",
+		"\r\n## crlf",
+		"item\n- nested\n  ## nested heading",
+		"> quote\n# h",
+		"a\n\n\n~~~\nb",
+		"   ### indented\n\t# tabbed",
+	} {
+		f.Add(seed)
+	}
+
+	// Entries are nested and inserted the way the capture commands do it
+	formatter, err := entry.NewFormatter(entry.DefaultFormat, "", time.UTC)
+	if err != nil {
+		f.Fatalf("Failed to create formatter: %v", err)
+	}
+	now := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+
+	before := Parse(fuzzNote)
+	other, _ := before.FindSection("## Other")
+	otherLines := before.Lines[other.Start:other.End]
+	frontmatter, _ := before.Frontmatter()
+
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, level := range []string{SanitizeStructural, SanitizeStrict} {
+			for _, position := range []Position{PositionAfterHeading, PositionBeforeEnd, PositionAfterLastListItem} {
+				lines, err := formatter.Format(entry.Data{Text: Sanitize(text, level), Time: now, Emoji: "⚡", Label: "Fleeting"})
+				if err != nil {
+					t.Fatalf("Failed to format %q: %v", text, err)
+				}
+
+				doc := Parse(fuzzNote)
+				if err := doc.AddEntry(lines, Options{Section: "## Log", Position: position}); err != nil {
+					t.Fatalf("%s/%s: failed to add entry %q: %v", level, position, lines, err)
+				}
+				after := Parse(doc.String())
+
+				if !slices.EqualFunc(after.Headings(), before.Headings(), func(a, b Block) bool {
+					return a.Level == b.Level && a.Text == b.Text
+				}) {
+					t.Fatalf("%s/%s: headings changed inserting %q:\n%q", level, position, lines, after.String())
+				}
+				if fm, ok := after.Frontmatter(); !ok || !slices.Equal(fm, frontmatter) {
+					t.Fatalf("%s/%s: frontmatter changed inserting %q:\n%q", level, position, lines, after.String())
+				}
+				sec, _ := after.FindSection("## Other")
+				if !slices.Equal(after.Lines[sec.Start:sec.End], otherLines) {
+					t.Fatalf("%s/%s: next section changed inserting %q:\n%q", level, position, lines, after.String())
+				}
+			}
+		}
+	})
+}
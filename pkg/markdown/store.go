@@ -0,0 +1,62 @@
+package markdown
+
+import (
+	"context"
+
+	"github.com/spf13/afero"
+)
+
+// Store is the filesystem notes and templates are read from and written to
+type Store interface {
+	// ReadFile returns the content of the file at path
+	ReadFile(ctx context.Context, path string) ([]byte, error)
+	// Update replaces the content of the file at path with the result of update,
+	// creating the file and its directories as needed. Implementations must not lose
+	// changes made to the file concurrently: they re-apply update or fail with
+	// ErrConcurrentModification instead.
+	Update(ctx context.Context, path string, update UpdateFunc) error
+}
+
+// UpdateFunc computes the new content of a file from its current content.
+// exists is false when the file did not exist or was empty.
+type UpdateFunc func(content []byte, exists bool) ([]byte, error)
+
+// FSStore is the Store of an afero filesystem, such as afero.NewMemMapFs() in tests
+// or a copy-on-write overlay for dry runs. Updates are written atomically, and hold
+// an advisory lock on notes of the OS filesystem.
+type FSStore struct {
+	Fs    afero.Fs
+	Debug bool
+}
+
+// ReadFile returns the content of the file at path
+func (s FSStore) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(s.Fs, path)
+}
+
+// Update applies update to the file at path
+func (s FSStore) Update(ctx context.Context, path string, update UpdateFunc) error {
+	return updateFile(ctx, s.Fs, path, s.Debug, update)
+}
+
+// LocalStore is the Store of the local disk
+type LocalStore struct {
+	Debug bool
+}
+
+// ReadFile returns the content of the file at path
+func (s LocalStore) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return s.fsStore().ReadFile(ctx, path)
+}
+
+// Update applies update to the file at path
+func (s LocalStore) Update(ctx context.Context, path string, update UpdateFunc) error {
+	return s.fsStore().Update(ctx, path, update)
+}
+
+func (s LocalStore) fsStore() FSStore {
+	return FSStore{Fs: afero.NewOsFs(), Debug: s.Debug}
+}
@@ -0,0 +1,92 @@
+package markdown
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestFSStore(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	if err := afero.WriteFile(fsys, "/vault/Templates/Daily.md", []byte("# {{date:YYYY-MM-DD}}\n\n## Log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	in := NewInserter(
+		WithStore(FSStore{Fs: fsys}),
+		WithSection("## Log"),
+		WithTemplate("/vault/Templates/Daily.md"),
+		WithClock(func() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }),
+	)
+	path := "/vault/Daily/2026/2026-10-17.md"
+	for _, entry := range []string{"- a", "- b"} {
+		if err := in.Insert(context.Background(), path, entry); err != nil {
+			t.Fatalf("Failed to insert entry: %v", err)
+		}
+	}
+
+	content, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if expected := "# 2026-10-17\n\n## Log\n- a\n- b\n"; string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+
+	info, err := fsys.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != defaultFileMode {
+		t.Errorf("Expected mode %v, got %v", defaultFileMode, info.Mode().Perm())
+	}
+	entries, err := afero.ReadDir(fsys, "/vault/Daily/2026")
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the note in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestFSStoreDryRun(t *testing.T) {
+	_, filePath := writeTestNote(t, "## Log\n- a\n")
+
+	// Writes land in memory, the disk is only read
+	overlay := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(afero.NewOsFs()), afero.NewMemMapFs())
+	in := NewInserter(WithStore(FSStore{Fs: overlay}), WithSection("## Log"))
+	if err := in.Insert(context.Background(), filePath, "- b"); err != nil {
+		t.Fatalf("Failed to insert entry: %v", err)
+	}
+
+	preview, err := afero.ReadFile(overlay, filePath)
+	if err != nil {
+		t.Fatalf("Failed to read overlay: %v", err)
+	}
+	if expected := "## Log\n- a\n- b\n"; string(preview) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, preview)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "## Log\n- a\n" {
+		t.Errorf("Expected the note on disk to be unchanged, got %q", content)
+	}
+}
+
+func TestFSStoreReadOnly(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	if err := afero.WriteFile(fsys, "/vault/day.md", []byte("## Log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	in := NewInserter(WithStore(FSStore{Fs: afero.NewReadOnlyFs(fsys)}), WithSection("## Log"))
+	if err := in.Insert(context.Background(), "/vault/day.md", "- a"); err == nil {
+		t.Error("Expected error writing to a read-only filesystem")
+	}
+}
@@ -0,0 +1,134 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// obsidianVarRe matches Obsidian core template variables: {{date}}, {{time}},
+	// {{title}}, {{date:YYYY-MM-DD}}, and the offset forms {{date+1d}} and
+	// {{yesterday:dddd}} markin supports so nav links don't need Templater
+	obsidianVarRe = regexp.MustCompile(`\{\{\s*(date|time|title|yesterday|tomorrow)\s*(?:([+-]\d+)([dwMy]))?\s*(?::([^}]*))?\}\}`)
+	// templaterRe matches the Templater commands markin understands, e.g.
+	// <% tp.date.now("YYYY-MM-DD", -1) %> or <% tp.file.title %>
+	templaterRe = regexp.MustCompile(`<%[-_]?\s*tp\.(date\.now|date\.yesterday|date\.tomorrow|date\.weekday|file\.title|file\.creation_date)\s*(?:\(([^)]*)\))?\s*[-_]?%>`)
+	// argRe matches the string and number arguments of a Templater call
+	argRe = regexp.MustCompile(`"([^"]*)"|'([^']*)'|(-?\d+)|(tp\.file\.title)`)
+)
+
+// Default formats of the Obsidian template variables
+const (
+	defaultDateFormat = "YYYY-MM-DD"
+	defaultTimeFormat = "HH:mm"
+)
+
+// RenderNoteTemplate renders an Obsidian note template for a note named title
+// created at t. It supports the core Templates plugin variables ({{date}},
+// {{time}}, {{title}}, {{date:FORMAT}}), offsets such as {{date-1d:FORMAT}},
+// {{yesterday}} and {{tomorrow}}, and the common Templater date and title commands.
+// Anything else is left untouched.
+func RenderNoteTemplate(text string, t time.Time, title string) string {
+	text = obsidianVarRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := obsidianVarRe.FindStringSubmatch(match)
+		name, amount, unit, format := m[1], m[2], m[3], strings.TrimSpace(m[4])
+
+		if name == "title" {
+			return title
+		}
+
+		ref := t
+		switch name {
+		case "yesterday":
+			ref = t.AddDate(0, 0, -1)
+		case "tomorrow":
+			ref = t.AddDate(0, 0, 1)
+		}
+		if amount != "" {
+			n, _ := strconv.Atoi(amount)
+			ref = shiftDate(ref, n, unit)
+		}
+
+		if format == "" {
+			format = defaultDateFormat
+			if name == "time" {
+				format = defaultTimeFormat
+			}
+		}
+		return FormatMoment(ref, format)
+	})
+
+	return templaterRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := templaterRe.FindStringSubmatch(match)
+		command, args := m[1], templaterArgs(m[2])
+
+		format := defaultDateFormat
+		if len(args) > 0 && args[0] != "" {
+			format = args[0]
+		}
+
+		switch command {
+		case "file.title":
+			return title
+		case "file.creation_date":
+			if len(args) == 0 {
+				format = "YYYY-MM-DD HH:mm"
+			}
+			return FormatMoment(t, format)
+		case "date.yesterday":
+			return FormatMoment(t.AddDate(0, 0, -1), format)
+		case "date.tomorrow":
+			return FormatMoment(t.AddDate(0, 0, 1), format)
+		case "date.weekday":
+			// tp.date.weekday(format, weekday) with 0 for the week's Sunday
+			weekday := 0
+			if len(args) > 1 {
+				weekday, _ = strconv.Atoi(args[1])
+			}
+			sunday := t.AddDate(0, 0, -int(t.Weekday()))
+			return FormatMoment(sunday.AddDate(0, 0, weekday), format)
+		default:
+			// tp.date.now(format, offset, reference, reference_format). The reference is
+			// the note title in daily note templates, which is the capture day.
+			offset := 0
+			if len(args) > 1 {
+				offset, _ = strconv.Atoi(args[1])
+			}
+			return FormatMoment(t.AddDate(0, 0, offset), format)
+		}
+	})
+}
+
+// templaterArgs splits the arguments of a Templater call
+func templaterArgs(s string) []string {
+	var args []string
+	for _, m := range argRe.FindAllStringSubmatch(s, -1) {
+		switch {
+		case m[4] != "":
+			args = append(args, m[4])
+		case m[3] != "":
+			args = append(args, m[3])
+		case m[2] != "":
+			args = append(args, m[2])
+		default:
+			args = append(args, m[1])
+		}
+	}
+	return args
+}
+
+// shiftDate moves t by n days (d), weeks (w), months (M) or years (y)
+func shiftDate(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return t.AddDate(0, 0, 7*n)
+	case "M":
+		return t.AddDate(0, n, 0)
+	case "y":
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
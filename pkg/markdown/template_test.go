@@ -0,0 +1,108 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderNoteTemplate(t *testing.T) {
+	ts := time.Date(2026, time.October, 17, 9, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"# {{title}}", "# 2026-10-17"},
+		{"created {{date}} {{time}}", "created 2026-10-17 09:05"},
+		{"{{date:dddd, MMMM Do YYYY}}", "Saturday, October 17th 2026"},
+		{"{{ date : YYYY-[W]WW }}", "2026-W42"},
+		{"<< [[{{yesterday}}]] | [[{{tomorrow}}]] >>", "<< [[2026-10-16]] | [[2026-10-18]] >>"},
+		{"{{date-1w:YYYY-MM-DD}} {{date+1M:YYYY-MM}} {{date+1y:YYYY}}", "2026-10-10 2026-11 2027"},
+		{`<% tp.date.now("YYYY-MM-DD") %>`, "2026-10-17"},
+		{`[[<% tp.date.now("YYYY-MM-DD", -1, tp.file.title, "YYYY-MM-DD") %>]]`, "[[2026-10-16]]"},
+		{`<% tp.date.yesterday("dddd") %> <% tp.date.tomorrow() %>`, "Friday 2026-10-18"},
+		{`<% tp.date.weekday("YYYY-MM-DD", 1) %>`, "2026-10-12"},
+		{"<% tp.file.title %>", "2026-10-17"},
+		{"{{unknown}} <% tp.system.prompt() %>", "{{unknown}} <% tp.system.prompt() %>"},
+	}
+
+	for _, tt := range tests {
+		if got := RenderNoteTemplate(tt.text, ts, "2026-10-17"); got != tt.expected {
+			t.Errorf("RenderNoteTemplate(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestAddEntryFromTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	templatePath := filepath.Join(tmpDir, "Templates", "Daily.md")
+	template := `---
+date: {{date}}
+---
+<< [[{{yesterday}}]] | [[{{tomorrow}}]] >>
+
+# {{date:dddd, MMMM Do YYYY}}
+
+## Log
+
+## Review
+`
+	if err := os.MkdirAll(filepath.Dir(templatePath), os.ModePerm); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	notePath := filepath.Join(tmpDir, "daily", "2026-10-17.md")
+	opts := Options{
+		Section:  "## Log",
+		Position: PositionBeforeEnd,
+		Template: templatePath,
+		Time:     time.Date(2026, time.October, 17, 9, 5, 0, 0, time.UTC),
+	}
+	if err := AddEntry(notePath, "- First", opts); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+	// The template is only used for new notes
+	if err := AddEntry(notePath, "- Second", opts); err != nil {
+		t.Fatalf("Failed to add entry: %v", err)
+	}
+
+	content, err := os.ReadFile(notePath)
+	if err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+
+	expected := `---
+date: 2026-10-17
+---
+<< [[2026-10-16]] | [[2026-10-18]] >>
+
+# Saturday, October 17th 2026
+
+## Log
+- First
+- Second
+
+## Review
+`
+	if string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+}
+
+func TestAddEntryMissingTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	notePath := filepath.Join(tmpDir, "2026-10-17.md")
+
+	err := AddEntry(notePath, "- First", Options{Section: "## Log", Template: filepath.Join(tmpDir, "missing.md")})
+	if err == nil {
+		t.Fatal("Expected error for missing template")
+	}
+	if _, err := os.Stat(notePath); !os.IsNotExist(err) {
+		t.Error("Expected no note to be created")
+	}
+}
@@ -0,0 +1,198 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WebDAVStore is the Store of a WebDAV share, such as a vault synced with Nextcloud.
+// Paths are relative to URL. Updates are PUT only if the note's ETag, or its
+// Last-Modified date when the server sends no ETag, is still the one it was read
+// with, and re-applied when the note changed in the meantime.
+type WebDAVStore struct {
+	// URL is the address of the share, e.g. https://cloud.example.com/remote.php/dav/files/me
+	URL      string
+	Username string
+	Password string
+	// Client makes the requests, a client giving up after webDAVTimeout when nil
+	Client *http.Client
+	Debug  bool
+}
+
+// webDAVTimeout bounds each request of the default client, so a capture against
+// an unreachable or stalled server fails instead of hanging
+const webDAVTimeout = 30 * time.Second
+
+// defaultWebDAVClient makes the requests of a WebDAVStore without Client
+var defaultWebDAVClient = &http.Client{Timeout: webDAVTimeout}
+
+// ReadFile returns the content of the file at path
+func (s WebDAVStore) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	content, _, exists, err := s.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+	}
+	return content, nil
+}
+
+// Update applies update to the file at path, creating its collections as needed
+func (s WebDAVStore) Update(ctx context.Context, path string, update UpdateFunc) error {
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		content, ver, exists, err := s.get(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		newContent, err := update(content, len(content) > 0)
+		if err != nil {
+			return err
+		}
+
+		header := http.Header{}
+		switch {
+		case !exists:
+			if err := s.mkcolAll(ctx, filepath.Dir(path)); err != nil {
+				return err
+			}
+			header.Set("If-None-Match", "*")
+		case ver.etag != "":
+			header.Set("If-Match", ver.etag)
+		case ver.lastModified != "":
+			// Only to the second, but still catches most concurrent edits
+			header.Set("If-Unmodified-Since", ver.lastModified)
+		default:
+			warnPrint("Warning: server sent no ETag or Last-Modified for %s, changes made meanwhile may be overwritten\n", path)
+		}
+
+		debugPrint(s.Debug, "Debug: Writing content to %s\n", path)
+		resp, err := s.do(ctx, http.MethodPut, path, newContent, header)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusPreconditionFailed:
+			if attempt >= maxUpdateAttempts {
+				return fmt.Errorf("%w: %s changed %d times while being updated", ErrConcurrentModification, path, attempt)
+			}
+			debugPrint(s.Debug, "Debug: File changed since it was read, re-applying (attempt %d)\n", attempt+1)
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		default:
+			return statusError(resp)
+		}
+	}
+}
+
+// version identifies the revision of a file a PUT is conditional on
+type version struct {
+	etag         string
+	lastModified string
+}
+
+// get returns the content and version of the file at path, reporting false when
+// it doesn't exist
+func (s WebDAVStore) get(ctx context.Context, path string) ([]byte, version, bool, error) {
+	resp, err := s.do(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, version{}, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, version{}, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, version{}, false, statusError(resp)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, version{}, false, fmt.Errorf("failed to read %s: %w", resp.Request.URL, err)
+	}
+	ver := version{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}
+	return content, ver, true, nil
+}
+
+// mkcolAll creates the collection dir and its parents, like os.MkdirAll
+func (s WebDAVStore) mkcolAll(ctx context.Context, dir string) error {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	if dir == "" || dir == "." {
+		return nil
+	}
+
+	parent := ""
+	for _, name := range strings.Split(dir, "/") {
+		parent = path.Join(parent, name)
+		resp, err := s.do(ctx, "MKCOL", parent+"/", nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405 Method Not Allowed means the collection already exists
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return statusError(resp)
+		}
+	}
+	return nil
+}
+
+// do sends a request for the file at path
+func (s WebDAVStore) do(ctx context.Context, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	u, err := s.fileURL(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = defaultWebDAVClient
+	}
+	return client.Do(req)
+}
+
+// fileURL returns the URL of the file at path, relative to the share
+func (s WebDAVStore) fileURL(p string) (string, error) {
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid WebDAV URL %q: %w", s.URL, err)
+	}
+	u := base.JoinPath(strings.Split(filepath.ToSlash(p), "/")...)
+	if strings.HasSuffix(p, "/") {
+		u.Path += "/"
+	}
+	return u.String(), nil
+}
+
+// statusError reports an unexpected response
+func statusError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s %s: %s, check the WebDAV credentials", resp.Request.Method, resp.Request.URL, resp.Status)
+	}
+	return fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL, resp.Status)
+}
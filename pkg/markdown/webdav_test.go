@@ -0,0 +1,326 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// newWebDAVServer starts an in-memory WebDAV server requiring the given basic auth
+// credentials. Like Nextcloud, it honors If-Match and If-None-Match on PUT.
+func newWebDAVServer(t *testing.T, username, password string) (*httptest.Server, webdav.FileSystem) {
+	t.Helper()
+	fsys := webdav.NewMemFS()
+	dav := &webdav.Handler{FileSystem: fsys, LockSystem: webdav.NewMemLS()}
+
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodPut {
+			head := httptest.NewRecorder()
+			dav.ServeHTTP(head, httptest.NewRequest(http.MethodHead, r.URL.EscapedPath(), nil))
+			exists := head.Code == http.StatusOK
+			if r.Header.Get("If-None-Match") == "*" && exists ||
+				r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != head.Header().Get("ETag") {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, fsys
+}
+
+// writeDAVFile writes a file to the server's filesystem, bypassing the server
+func writeDAVFile(t *testing.T, fsys webdav.FileSystem, name, content string) {
+	t.Helper()
+	ctx := context.Background()
+	dir := name[:strings.LastIndex(name, "/")]
+	parent := ""
+	for _, d := range strings.Split(strings.Trim(dir, "/"), "/") {
+		parent += "/" + d
+		fsys.Mkdir(ctx, parent, 0755)
+	}
+	f, err := fsys.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// readDAVFile reads a file from the server's filesystem
+func readDAVFile(t *testing.T, fsys webdav.FileSystem, name string) string {
+	t.Helper()
+	f, err := fsys.OpenFile(context.Background(), name, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+// hookTransport calls beforePut ahead of every PUT request
+type hookTransport struct {
+	beforePut func()
+}
+
+func (h hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut {
+		h.beforePut()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// stripTransport removes headers from the responses, like servers that don't send
+// them, and records the headers of the PUT requests
+type stripTransport struct {
+	strip []string
+	puts  *[]http.Header
+}
+
+func (s stripTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut {
+		*s.puts = append(*s.puts, req.Header.Clone())
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range s.strip {
+		resp.Header.Del(h)
+	}
+	return resp, nil
+}
+
+func TestWebDAVStore(t *testing.T) {
+	srv, fsys := newWebDAVServer(t, "me", "secret")
+	writeDAVFile(t, fsys, "/Vault/Templates/Daily.md", "# {{date:YYYY-MM-DD}}\n\n## Log\n")
+
+	store := WebDAVStore{URL: srv.URL + "/Vault", Username: "me", Password: "secret"}
+	in := NewInserter(
+		WithStore(store),
+		WithSection("## Log"),
+		WithTemplate("Templates/Daily.md"),
+		WithClock(func() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }),
+	)
+	for _, entry := range []string{"- a", "- b"} {
+		if err := in.Insert(context.Background(), "Daily/2026/2026-10-17 Sat.md", entry); err != nil {
+			t.Fatalf("Failed to insert entry: %v", err)
+		}
+	}
+
+	expected := "# 2026-10-17\n\n## Log\n- a\n- b\n"
+	if got := readDAVFile(t, fsys, "/Vault/Daily/2026/2026-10-17 Sat.md"); got != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, got)
+	}
+
+	content, err := store.ReadFile(context.Background(), "Daily/2026/2026-10-17 Sat.md")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, content)
+	}
+	if _, err := store.ReadFile(context.Background(), "Daily/missing.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestWebDAVStoreConditionalPut(t *testing.T) {
+	srv, fsys := newWebDAVServer(t, "me", "secret")
+
+	var puts []http.Header
+	client := &http.Client{Transport: stripTransport{puts: &puts}}
+	store := WebDAVStore{URL: srv.URL, Username: "me", Password: "secret", Client: client}
+	in := NewInserter(WithStore(store), WithSection("## Log"))
+
+	// Creating the note must not replace one created in the meantime
+	if err := in.Insert(context.Background(), "day.md", "- a"); err != nil {
+		t.Fatalf("Failed to insert entry: %v", err)
+	}
+	if len(puts) != 1 || puts[0].Get("If-None-Match") != "*" || puts[0].Get("If-Match") != "" {
+		t.Fatalf("Expected the create to be sent with If-None-Match: *, got %v", puts)
+	}
+
+	req := httptest.NewRequest(http.MethodHead, "/day.md", nil)
+	req.SetBasicAuth("me", "secret")
+	head := httptest.NewRecorder()
+	srv.Config.Handler.ServeHTTP(head, req)
+	etag := head.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected the server to send an ETag")
+	}
+
+	// Updating it must only replace the version it was read at
+	if err := in.Insert(context.Background(), "day.md", "- b"); err != nil {
+		t.Fatalf("Failed to insert entry: %v", err)
+	}
+	if len(puts) != 2 || puts[1].Get("If-Match") != etag || puts[1].Get("If-None-Match") != "" {
+		t.Errorf("Expected the update to be sent with If-Match: %s, got %v", etag, puts[1])
+	}
+	if got := readDAVFile(t, fsys, "/day.md"); got != "## Log\n- a\n- b\n" {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", "## Log\n- a\n- b\n", got)
+	}
+}
+
+func TestWebDAVStoreWithoutETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		strip    []string
+		expected string
+	}{
+		{"last modified", []string{"ETag"}, "If-Unmodified-Since"},
+		{"no version", []string{"ETag", "Last-Modified"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, fsys := newWebDAVServer(t, "me", "secret")
+			writeDAVFile(t, fsys, "/day.md", "## Log\n- a\n")
+
+			var puts []http.Header
+			client := &http.Client{Transport: stripTransport{strip: tt.strip, puts: &puts}}
+			store := WebDAVStore{URL: srv.URL, Username: "me", Password: "secret", Client: client}
+			if err := NewInserter(WithStore(store), WithSection("## Log")).Insert(context.Background(), "day.md", "- b"); err != nil {
+				t.Fatalf("Failed to insert entry: %v", err)
+			}
+
+			expected := "## Log\n- a\n- b\n"
+			if got := readDAVFile(t, fsys, "/day.md"); got != expected {
+				t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, got)
+			}
+			if len(puts) != 1 {
+				t.Fatalf("Expected 1 PUT, got %d", len(puts))
+			}
+			for _, h := range []string{"If-Match", "If-None-Match", "If-Unmodified-Since"} {
+				if got := puts[0].Get(h); (got != "") != (h == tt.expected) {
+					t.Errorf("Unexpected %s header %q", h, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWebDAVStoreDefaultClientTimeout(t *testing.T) {
+	if defaultWebDAVClient.Timeout <= 0 {
+		t.Fatal("Expected the default client to time out")
+	}
+
+	// A server that accepts the request but never answers
+	stalled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer srv.Close()
+	defer close(stalled)
+
+	orig := defaultWebDAVClient
+	defaultWebDAVClient = &http.Client{Timeout: 50 * time.Millisecond}
+	defer func() { defaultWebDAVClient = orig }()
+
+	store := WebDAVStore{URL: srv.URL}
+	if _, err := store.ReadFile(context.Background(), "day.md"); err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
+
+func TestWebDAVStoreReappliesAfterExternalEdit(t *testing.T) {
+	srv, fsys := newWebDAVServer(t, "me", "secret")
+	writeDAVFile(t, fsys, "/day.md", "## Log\n- a\n")
+
+	// Another device edits the note between the read and the first write
+	edited := false
+	client := &http.Client{Transport: hookTransport{beforePut: func() {
+		if !edited {
+			edited = true
+			writeDAVFile(t, fsys, "/day.md", "## Log\n- a\n- from phone\n")
+		}
+	}}}
+
+	store := WebDAVStore{URL: srv.URL, Username: "me", Password: "secret", Client: client}
+	if err := NewInserter(WithStore(store), WithSection("## Log")).Insert(context.Background(), "day.md", "- b"); err != nil {
+		t.Fatalf("Failed to insert entry: %v", err)
+	}
+
+	expected := "## Log\n- a\n- from phone\n- b\n"
+	if got := readDAVFile(t, fsys, "/day.md"); got != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, got)
+	}
+}
+
+func TestWebDAVStoreCreatedConcurrently(t *testing.T) {
+	srv, fsys := newWebDAVServer(t, "me", "secret")
+
+	// The note is created elsewhere after markin found it missing
+	created := false
+	client := &http.Client{Transport: hookTransport{beforePut: func() {
+		if !created {
+			created = true
+			writeDAVFile(t, fsys, "/Daily/day.md", "## Log\n- from phone\n")
+		}
+	}}}
+
+	store := WebDAVStore{URL: srv.URL, Username: "me", Password: "secret", Client: client}
+	if err := NewInserter(WithStore(store), WithSection("## Log")).Insert(context.Background(), "Daily/day.md", "- b"); err != nil {
+		t.Fatalf("Failed to insert entry: %v", err)
+	}
+
+	expected := "## Log\n- from phone\n- b\n"
+	if got := readDAVFile(t, fsys, "/Daily/day.md"); got != expected {
+		t.Errorf("Content mismatch.\nExpected:\n%q\nGot:\n%q", expected, got)
+	}
+}
+
+func TestWebDAVStoreGivesUpOnContinuousEdits(t *testing.T) {
+	srv, fsys := newWebDAVServer(t, "me", "secret")
+	writeDAVFile(t, fsys, "/day.md", "## Log\n")
+
+	edits := 0
+	client := &http.Client{Transport: hookTransport{beforePut: func() {
+		edits++
+		writeDAVFile(t, fsys, "/day.md", "## Log\n"+strings.Repeat("- edit\n", edits))
+	}}}
+
+	store := WebDAVStore{URL: srv.URL, Username: "me", Password: "secret", Client: client}
+	err := NewInserter(WithStore(store), WithSection("## Log")).Insert(context.Background(), "day.md", "- b")
+	if !errors.Is(err, ErrConcurrentModification) {
+		t.Fatalf("Expected ErrConcurrentModification, got %v", err)
+	}
+	if got := readDAVFile(t, fsys, "/day.md"); strings.Contains(got, "- b") {
+		t.Errorf("Expected the external edit to be kept, got %q", got)
+	}
+}
+
+func TestWebDAVStoreUnauthorized(t *testing.T) {
+	srv, _ := newWebDAVServer(t, "me", "secret")
+
+	store := WebDAVStore{URL: srv.URL, Username: "me", Password: "wrong"}
+	err := NewInserter(WithStore(store), WithSection("## Log")).Insert(context.Background(), "day.md", "- b")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected 401 error, got %v", err)
+	}
+}